@@ -0,0 +1,32 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a status condition of Policy and Role
+type ConditionType string
+
+// +kubebuilder:validation:Enum=True;False;Unknown
+type ConditionStatus string
+
+// Condition describes one aspect of the state of an object, following the shape of Kubernetes conditions
+type Condition struct {
+	// Type of the condition, one of Ready, Synced or Degraded
+	Type ConditionType `json:"type"`
+
+	// Status of the condition, one of True, False or Unknown
+	Status ConditionStatus `json:"status"`
+
+	// ObservedGeneration is the generation of the object the condition was set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastTransitionTime is the last time the status of the condition changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Reason is a machine-readable CamelCase explanation of the status
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human-readable explanation of the status
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,326 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ory/keto-maester/api/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// HubSpecAnnotation keeps the fields of the v1alpha2 spec and status which v1alpha1 can't represent,
+// so that they survive reading and writing an object as v1alpha1
+const HubSpecAnnotation = "keto.ory.sh/v1alpha2-spec"
+
+// policyHubSpec are the fields of the v1alpha2 policy spec missing in v1alpha1
+type policyHubSpec struct {
+	KetoID         string                  `json:"ketoId,omitempty"`
+	SubjectRefs    []v1alpha2.RoleRef      `json:"subjectRefs,omitempty"`
+	DeletionPolicy v1alpha2.DeletionPolicy `json:"deletionPolicy,omitempty"`
+	Status         *policyHubStatus        `json:"status,omitempty"`
+}
+
+// policyHubStatus are the fields of the v1alpha2 policy status missing in v1alpha1, the controller finds
+// the policy in ORY Keto by them
+type policyHubStatus struct {
+	KetoID           string           `json:"ketoId,omitempty"`
+	Flavour          v1alpha2.Flavour `json:"flavour,omitempty"`
+	ResolvedSubjects []string         `json:"resolvedSubjects,omitempty"`
+}
+
+// roleHubSpec are the fields of the v1alpha2 role spec missing in v1alpha1
+type roleHubSpec struct {
+	KetoID         string                   `json:"ketoId,omitempty"`
+	MemberRefs     []v1alpha2.MemberRef     `json:"memberRefs,omitempty"`
+	MemberSelector *v1alpha2.MemberSelector `json:"memberSelector,omitempty"`
+	DeletionPolicy v1alpha2.DeletionPolicy  `json:"deletionPolicy,omitempty"`
+	Status         *roleHubStatus           `json:"status,omitempty"`
+}
+
+// roleHubStatus are the fields of the v1alpha2 role status missing in v1alpha1, see policyHubStatus
+type roleHubStatus struct {
+	KetoID          string           `json:"ketoId,omitempty"`
+	Flavour         v1alpha2.Flavour `json:"flavour,omitempty"`
+	ResolvedMembers []string         `json:"resolvedMembers,omitempty"`
+}
+
+// ConvertTo converts the policy to the v1alpha2 hub version
+func (src *Policy) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha2.Policy)
+
+	var hubSpec policyHubSpec
+	meta, err := popHubSpec(src.ObjectMeta, &hubSpec)
+	if err != nil {
+		return err
+	}
+	dst.ObjectMeta = meta
+
+	conditions, err := convertPolicyConditionsTo(src.Spec)
+	if err != nil {
+		return err
+	}
+
+	dst.Spec = v1alpha2.PolicySpec{
+		KetoID:         hubSpec.KetoID,
+		Flavour:        v1alpha2.Flavour(src.Spec.PatternMatching),
+		Description:    src.Spec.Description,
+		Subjects:       src.Spec.Subjects,
+		SubjectRefs:    hubSpec.SubjectRefs,
+		Actions:        src.Spec.Actions,
+		Effect:         v1alpha2.Effect(src.Spec.Effect),
+		Resources:      src.Spec.Resources,
+		Conditions:     conditions,
+		DeletionPolicy: hubSpec.DeletionPolicy,
+	}
+	dst.Status = v1alpha2.PolicyStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         convertStatusConditionsTo(src.Status.Conditions),
+	}
+	if hubStatus := hubSpec.Status; hubStatus != nil {
+		dst.Status.KetoID = hubStatus.KetoID
+		dst.Status.Flavour = hubStatus.Flavour
+		dst.Status.ResolvedSubjects = hubStatus.ResolvedSubjects
+	}
+	return nil
+}
+
+// ConvertFrom converts the policy from the v1alpha2 hub version
+func (dst *Policy) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha2.Policy)
+
+	hubSpec := policyHubSpec{
+		KetoID:         src.Spec.KetoID,
+		SubjectRefs:    src.Spec.SubjectRefs,
+		DeletionPolicy: src.Spec.DeletionPolicy,
+	}
+	if src.Status.KetoID != "" || src.Status.Flavour != "" || len(src.Status.ResolvedSubjects) > 0 {
+		hubSpec.Status = &policyHubStatus{
+			KetoID:           src.Status.KetoID,
+			Flavour:          src.Status.Flavour,
+			ResolvedSubjects: src.Status.ResolvedSubjects,
+		}
+	}
+	meta, err := pushHubSpec(src.ObjectMeta, hubSpec)
+	if err != nil {
+		return err
+	}
+	dst.ObjectMeta = meta
+
+	var conditions map[string]PolicyCondition
+	if err := convertJSON(src.Spec.Conditions, &conditions); err != nil {
+		return err
+	}
+
+	dst.Spec = PolicySpec{
+		PatternMatching: PatternMatching(src.Spec.Flavour),
+		Description:     src.Spec.Description,
+		Subjects:        src.Spec.Subjects,
+		Actions:         src.Spec.Actions,
+		Effect:          Action(src.Spec.Effect),
+		Resources:       src.Spec.Resources,
+		Conditions:      conditions,
+	}
+	dst.Status = PolicyStatus{
+		ObservedGeneration:  src.Status.ObservedGeneration,
+		ReconciliationError: reconciliationErrorFrom(src.Status.Conditions),
+		Conditions:          convertStatusConditionsFrom(src.Status.Conditions),
+	}
+	return nil
+}
+
+// ConvertTo converts the role to the v1alpha2 hub version
+func (src *Role) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha2.Role)
+
+	var hubSpec roleHubSpec
+	meta, err := popHubSpec(src.ObjectMeta, &hubSpec)
+	if err != nil {
+		return err
+	}
+	dst.ObjectMeta = meta
+
+	dst.Spec = v1alpha2.RoleSpec{
+		KetoID:         hubSpec.KetoID,
+		Flavour:        v1alpha2.Flavour(src.Spec.PatternMatching),
+		Members:        src.Spec.Members,
+		MemberRefs:     hubSpec.MemberRefs,
+		MemberSelector: hubSpec.MemberSelector,
+		MembershipMode: v1alpha2.MembershipMode(src.Spec.MembershipMode),
+		DeletionPolicy: hubSpec.DeletionPolicy,
+	}
+	dst.Status = v1alpha2.RoleStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		ManagedMembers:     src.Status.ManagedMembers,
+		Conditions:         convertStatusConditionsTo(src.Status.Conditions),
+	}
+	if hubStatus := hubSpec.Status; hubStatus != nil {
+		dst.Status.KetoID = hubStatus.KetoID
+		dst.Status.Flavour = hubStatus.Flavour
+		dst.Status.ResolvedMembers = hubStatus.ResolvedMembers
+	}
+	return nil
+}
+
+// ConvertFrom converts the role from the v1alpha2 hub version
+func (dst *Role) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha2.Role)
+
+	hubSpec := roleHubSpec{
+		KetoID:         src.Spec.KetoID,
+		MemberRefs:     src.Spec.MemberRefs,
+		MemberSelector: src.Spec.MemberSelector,
+		DeletionPolicy: src.Spec.DeletionPolicy,
+	}
+	if src.Status.KetoID != "" || src.Status.Flavour != "" || len(src.Status.ResolvedMembers) > 0 {
+		hubSpec.Status = &roleHubStatus{
+			KetoID:          src.Status.KetoID,
+			Flavour:         src.Status.Flavour,
+			ResolvedMembers: src.Status.ResolvedMembers,
+		}
+	}
+	meta, err := pushHubSpec(src.ObjectMeta, hubSpec)
+	if err != nil {
+		return err
+	}
+	dst.ObjectMeta = meta
+
+	dst.Spec = RoleSpec{
+		PatternMatching: PatternMatching(src.Spec.Flavour),
+		Members:         src.Spec.Members,
+		MembershipMode:  MembershipMode(src.Spec.MembershipMode),
+	}
+	dst.Status = RoleStatus{
+		ObservedGeneration:  src.Status.ObservedGeneration,
+		ReconciliationError: reconciliationErrorFrom(src.Status.Conditions),
+		ManagedMembers:      src.Status.ManagedMembers,
+		Conditions:          convertStatusConditionsFrom(src.Status.Conditions),
+	}
+	return nil
+}
+
+// convertPolicyConditionsTo merges the typed and the raw conditions of a v1alpha1 policy
+func convertPolicyConditionsTo(spec PolicySpec) (map[string]v1alpha2.PolicyCondition, error) {
+	var conditions map[string]v1alpha2.PolicyCondition
+	if err := convertJSON(spec.Conditions, &conditions); err != nil {
+		return nil, err
+	}
+
+	if spec.RawConditions == nil {
+		return conditions, nil
+	}
+	raw, err := v1alpha2.PolicyConditionsFromKeto(spec.RawConditions.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition: %s", err)
+	}
+	for name, condition := range raw {
+		if _, exists := conditions[name]; exists {
+			return nil, fmt.Errorf("condition %q is set in both conditions and condition", name)
+		}
+		if conditions == nil {
+			conditions = map[string]v1alpha2.PolicyCondition{}
+		}
+		conditions[name] = condition
+	}
+	return conditions, nil
+}
+
+func convertStatusConditionsTo(conditions []Condition) []v1alpha2.Condition {
+	if conditions == nil {
+		return nil
+	}
+	converted := make([]v1alpha2.Condition, len(conditions))
+	for i, c := range conditions {
+		converted[i] = v1alpha2.Condition{
+			Type:               v1alpha2.ConditionType(c.Type),
+			Status:             v1alpha2.ConditionStatus(c.Status),
+			ObservedGeneration: c.ObservedGeneration,
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		}
+	}
+	return converted
+}
+
+func convertStatusConditionsFrom(conditions []v1alpha2.Condition) []Condition {
+	if conditions == nil {
+		return nil
+	}
+	converted := make([]Condition, len(conditions))
+	for i, c := range conditions {
+		converted[i] = Condition{
+			Type:               ConditionType(c.Type),
+			Status:             ConditionStatus(c.Status),
+			ObservedGeneration: c.ObservedGeneration,
+			LastTransitionTime: c.LastTransitionTime,
+			Reason:             c.Reason,
+			Message:            c.Message,
+		}
+	}
+	return converted
+}
+
+// reconciliationErrorFrom fills the error description of v1alpha1 in from the Degraded condition
+func reconciliationErrorFrom(conditions []v1alpha2.Condition) ReconciliationError {
+	if degraded := v1alpha2.FindCondition(conditions, v1alpha2.ConditionDegraded); degraded != nil && degraded.Status == v1alpha2.ConditionTrue {
+		return ReconciliationError{Description: degraded.Message}
+	}
+	return ReconciliationError{}
+}
+
+// pushHubSpec returns a copy of meta with hubSpec stored in the annotations, unless it is empty
+func pushHubSpec(meta metav1.ObjectMeta, hubSpec interface{}) (metav1.ObjectMeta, error) {
+	encoded, err := json.Marshal(hubSpec)
+	if err != nil {
+		return meta, err
+	}
+
+	annotations := map[string]string{}
+	for k, v := range meta.Annotations {
+		annotations[k] = v
+	}
+	delete(annotations, HubSpecAnnotation)
+	if string(encoded) != "{}" {
+		annotations[HubSpecAnnotation] = string(encoded)
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+
+	meta.Annotations = annotations
+	return meta, nil
+}
+
+// popHubSpec reads hubSpec from the annotations of meta and returns a copy of meta without it
+func popHubSpec(meta metav1.ObjectMeta, hubSpec interface{}) (metav1.ObjectMeta, error) {
+	encoded, ok := meta.Annotations[HubSpecAnnotation]
+	if !ok {
+		return meta, nil
+	}
+	if err := json.Unmarshal([]byte(encoded), hubSpec); err != nil {
+		return meta, fmt.Errorf("invalid annotation %s: %s", HubSpecAnnotation, err)
+	}
+
+	annotations := map[string]string{}
+	for k, v := range meta.Annotations {
+		if k != HubSpecAnnotation {
+			annotations[k] = v
+		}
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+
+	meta.Annotations = annotations
+	return meta, nil
+}
+
+// convertJSON copies between types of both versions which share the same JSON representation
+func convertJSON(in, out interface{}) error {
+	encoded, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, out)
+}
@@ -0,0 +1,282 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestPolicyConversion(t *testing.T) {
+
+	t.Run("round trip through the hub", func(t *testing.T) {
+
+		//given
+		policy := &Policy{
+			ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "n", Annotations: map[string]string{"a": "b"}},
+			Spec: PolicySpec{
+				PatternMatching: "glob",
+				Description:     "d",
+				Subjects:        []string{"s"},
+				Actions:         []string{"get"},
+				Effect:          "allow",
+				Resources:       []string{"r"},
+				Conditions:      map[string]PolicyCondition{"owner": {Subject: &EqualsSubjectCondition{}}},
+			},
+		}
+
+		//when
+		hub := &v1alpha2.Policy{}
+		require.NoError(t, policy.ConvertTo(hub))
+		converted := &Policy{}
+		require.NoError(t, converted.ConvertFrom(hub))
+
+		//then
+		assert.Equal(t, v1alpha2.Flavour("glob"), hub.Spec.Flavour)
+		assert.Equal(t, v1alpha2.EffectAllow, hub.Spec.Effect)
+		assert.Equal(t, policy, converted)
+	})
+
+	t.Run("keeps the keto id and deletion policy in an annotation", func(t *testing.T) {
+
+		//given
+		hub := &v1alpha2.Policy{
+			ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "n"},
+			Spec:       v1alpha2.PolicySpec{KetoID: "legacy-id", DeletionPolicy: v1alpha2.DeletionPolicyRetain},
+		}
+
+		//when
+		policy := &Policy{}
+		require.NoError(t, policy.ConvertFrom(hub))
+		converted := &v1alpha2.Policy{}
+		require.NoError(t, policy.ConvertTo(converted))
+
+		//then
+		assert.Equal(t, `{"ketoId":"legacy-id","deletionPolicy":"Retain"}`, policy.Annotations[HubSpecAnnotation])
+		assert.Equal(t, "legacy-id", converted.Spec.KetoID)
+		assert.Equal(t, v1alpha2.DeletionPolicyRetain, converted.Spec.DeletionPolicy)
+		assert.Empty(t, converted.Annotations)
+	})
+
+	t.Run("merges raw conditions", func(t *testing.T) {
+
+		//given
+		policy := &Policy{
+			Spec: PolicySpec{
+				Conditions:    map[string]PolicyCondition{"owner": {Subject: &EqualsSubjectCondition{}}},
+				RawConditions: &runtime.RawExtension{Raw: []byte(`{"remoteIP":{"type":"CIDRCondition","options":{"cidr":"10.0.0.0/8"}}}`)},
+			},
+		}
+
+		//when
+		hub := &v1alpha2.Policy{}
+		err := policy.ConvertTo(hub)
+
+		//then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]v1alpha2.PolicyCondition{
+			"owner":    {Subject: &v1alpha2.EqualsSubjectCondition{}},
+			"remoteIP": {CIDR: &v1alpha2.CIDRCondition{CIDR: "10.0.0.0/8"}},
+		}, hub.Spec.Conditions)
+	})
+
+	t.Run("rejects conditions set twice", func(t *testing.T) {
+
+		//given
+		policy := &Policy{
+			Spec: PolicySpec{
+				Conditions:    map[string]PolicyCondition{"owner": {Subject: &EqualsSubjectCondition{}}},
+				RawConditions: &runtime.RawExtension{Raw: []byte(`{"owner":{"type":"EqualsSubjectCondition","options":{}}}`)},
+			},
+		}
+
+		//when
+		err := policy.ConvertTo(&v1alpha2.Policy{})
+
+		//then
+		assert.Error(t, err)
+	})
+
+	t.Run("fills the reconciliation error in from the degraded condition", func(t *testing.T) {
+
+		//given
+		hub := &v1alpha2.Policy{
+			Status: v1alpha2.PolicyStatus{Conditions: []v1alpha2.Condition{
+				{Type: v1alpha2.ConditionDegraded, Status: v1alpha2.ConditionTrue, Reason: v1alpha2.ReasonKetoRejected, Message: "bad request"},
+			}},
+		}
+
+		//when
+		policy := &Policy{}
+		require.NoError(t, policy.ConvertFrom(hub))
+
+		//then
+		assert.Equal(t, "bad request", policy.Status.ReconciliationError.Description)
+		assert.Len(t, policy.Status.Conditions, 1)
+	})
+}
+
+func TestStoredObjectUpgrade(t *testing.T) {
+
+	t.Run("policy keeps its flavour and conditions", func(t *testing.T) {
+
+		//given
+		stored := `{"apiVersion":"keto.ory.sh/v1alpha1","kind":"Policy","metadata":{"name":"p","namespace":"n","generation":3},` +
+			`"spec":{"pattern_matching":"regex","subjects":["users:<.*>"],"actions":["get"],"effect":"allow","resources":["r"],` +
+			`"conditions":{"owner":{"subject":{}}},"condition":{"remoteIP":{"type":"CIDRCondition","options":{"cidr":"10.0.0.0/8"}}}},` +
+			`"status":{"observedGeneration":3}}`
+		policy := &Policy{}
+		require.NoError(t, json.Unmarshal([]byte(stored), policy))
+
+		//when
+		hub := &v1alpha2.Policy{}
+		require.NoError(t, policy.ConvertTo(hub))
+		policyJSON, err := hub.ToPolicyJSON()
+		require.NoError(t, err)
+		downgraded := &Policy{}
+		require.NoError(t, downgraded.ConvertFrom(hub))
+		upgraded := &v1alpha2.Policy{}
+		require.NoError(t, downgraded.ConvertTo(upgraded))
+
+		//then
+		assert.Equal(t, v1alpha2.Flavour("regex"), hub.Spec.Flavour)
+		assert.Equal(t, v1alpha2.EffectAllow, hub.Spec.Effect)
+		assert.Equal(t, "n:p", policyJSON.Id)
+		assert.JSONEq(t, `{"owner":{"type":"EqualsSubjectCondition","options":{}},"remoteIP":{"type":"CIDRCondition","options":{"cidr":"10.0.0.0/8"}}}`, string(policyJSON.Conditions))
+		assert.Equal(t, int64(3), hub.Status.ObservedGeneration)
+		assert.Equal(t, hub.Spec, upgraded.Spec)
+	})
+
+	t.Run("role keeps its flavour and managed members", func(t *testing.T) {
+
+		//given
+		stored := `{"apiVersion":"keto.ory.sh/v1alpha1","kind":"Role","metadata":{"name":"r","namespace":"n"},` +
+			`"spec":{"pattern_matching":"glob","members":["a","b"],"membershipMode":"merge"},` +
+			`"status":{"observedGeneration":1,"managedMembers":["a"]}}`
+		role := &Role{}
+		require.NoError(t, json.Unmarshal([]byte(stored), role))
+
+		//when
+		hub := &v1alpha2.Role{}
+		require.NoError(t, role.ConvertTo(hub))
+		downgraded := &Role{}
+		require.NoError(t, downgraded.ConvertFrom(hub))
+
+		//then
+		assert.Equal(t, v1alpha2.Flavour("glob"), hub.Spec.Flavour)
+		assert.Equal(t, v1alpha2.MembershipMerge, hub.Spec.MembershipMode)
+		assert.Equal(t, []string{"a"}, hub.Status.ManagedMembers)
+		assert.Equal(t, role.Spec, downgraded.Spec)
+		assert.Equal(t, role.Status, downgraded.Status)
+	})
+}
+
+func TestRoleConversion(t *testing.T) {
+
+	//given
+	role := &Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "r", Namespace: "n"},
+		Spec:       RoleSpec{PatternMatching: "regex", Members: []string{"a", "b"}, MembershipMode: MembershipMerge},
+		Status:     RoleStatus{ObservedGeneration: 2, ManagedMembers: []string{"a"}},
+	}
+
+	//when
+	hub := &v1alpha2.Role{}
+	require.NoError(t, role.ConvertTo(hub))
+	hub.Spec.KetoID = "legacy-role"
+	hub.Spec.MemberRefs = []v1alpha2.MemberRef{{Name: "deployer"}}
+	hub.Spec.DeletionPolicy = v1alpha2.DeletionPolicyRetain
+	converted := &Role{}
+	require.NoError(t, converted.ConvertFrom(hub))
+	back := &v1alpha2.Role{}
+	require.NoError(t, converted.ConvertTo(back))
+
+	//then
+	assert.Equal(t, v1alpha2.Flavour("regex"), hub.Spec.Flavour)
+	assert.Equal(t, v1alpha2.MembershipMerge, hub.Spec.MembershipMode)
+	assert.Equal(t, role.Spec, converted.Spec)
+	assert.Equal(t, role.Status, converted.Status)
+	assert.Equal(t, `{"ketoId":"legacy-role","memberRefs":[{"name":"deployer"}],"deletionPolicy":"Retain"}`, converted.Annotations[HubSpecAnnotation])
+	assert.Equal(t, hub.Spec, back.Spec)
+}
+
+func TestHubStatusRoundTrip(t *testing.T) {
+
+	conditions := []v1alpha2.Condition{{Type: v1alpha2.ConditionSynced, Status: v1alpha2.ConditionTrue, Reason: v1alpha2.ReasonSynced}}
+
+	t.Run("policy keeps where it was applied", func(t *testing.T) {
+
+		//given
+		hub := &v1alpha2.Policy{
+			ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "n"},
+			Spec:       v1alpha2.PolicySpec{Flavour: "regex", Subjects: []string{"s"}, SubjectRefs: []v1alpha2.RoleRef{{Name: "readers"}}},
+			Status: v1alpha2.PolicyStatus{
+				ObservedGeneration: 3,
+				KetoID:             "n:p",
+				Flavour:            "exact",
+				ResolvedSubjects:   []string{"n:readers"},
+				Conditions:         conditions,
+			},
+		}
+
+		//when
+		policy := &Policy{}
+		require.NoError(t, policy.ConvertFrom(hub))
+		back := &v1alpha2.Policy{}
+		require.NoError(t, policy.ConvertTo(back))
+
+		//then
+		assert.Equal(t, hub, back)
+	})
+
+	t.Run("role keeps where it was applied", func(t *testing.T) {
+
+		//given
+		hub := &v1alpha2.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "r", Namespace: "n", Annotations: map[string]string{"a": "b"}},
+			Spec:       v1alpha2.RoleSpec{Flavour: "glob", Members: []string{"a"}, MemberRefs: []v1alpha2.MemberRef{{Name: "deployer"}}},
+			Status: v1alpha2.RoleStatus{
+				ObservedGeneration: 2,
+				KetoID:             "legacy-role",
+				Flavour:            "exact",
+				ResolvedMembers:    []string{"system:serviceaccount:n:deployer"},
+				ManagedMembers:     []string{"a"},
+				Conditions:         conditions,
+			},
+		}
+
+		//when
+		role := &Role{}
+		require.NoError(t, role.ConvertFrom(hub))
+		back := &v1alpha2.Role{}
+		require.NoError(t, role.ConvertTo(back))
+
+		//then
+		assert.Equal(t, hub, back)
+	})
+
+	t.Run("status written as v1alpha1 keeps the annotated fields", func(t *testing.T) {
+
+		//given
+		hub := &v1alpha2.Policy{
+			ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "n"},
+			Status:     v1alpha2.PolicyStatus{KetoID: "n:p", Flavour: "glob"},
+		}
+		policy := &Policy{}
+		require.NoError(t, policy.ConvertFrom(hub))
+
+		//when
+		policy.Status.ObservedGeneration = 4
+		back := &v1alpha2.Policy{}
+		require.NoError(t, policy.ConvertTo(back))
+
+		//then
+		assert.Equal(t, int64(4), back.Status.ObservedGeneration)
+		assert.Equal(t, "n:p", back.Status.KetoID)
+		assert.Equal(t, v1alpha2.Flavour("glob"), back.Status.Flavour)
+	})
+}
@@ -1,4 +1,5 @@
-// Package v1alpha1 contains API Schema definitions for the keto v1alpha1 API group
+// Package v1alpha1 contains API Schema definitions for the keto v1alpha1 API group.
+// Objects are stored as v1alpha2 and converted to and from v1alpha1 by the conversion webhook.
 // +kubebuilder:object:generate=true
 // +groupName=keto.ory.sh
 package v1alpha1
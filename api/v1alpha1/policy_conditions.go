@@ -0,0 +1,73 @@
+package v1alpha1
+
+import (
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PolicyCondition is one of the conditions of ORY Keto (see https://www.ory.sh/keto/docs/engines/acp-ory#conditions),
+// exactly one of its fields must be set
+type PolicyCondition struct {
+	// StringEqual is fulfilled if the value is equal to a string
+	StringEqual *StringEqualCondition `json:"stringEqual,omitempty"`
+
+	// StringMatch is fulfilled if the value matches a regular expression
+	StringMatch *StringMatchCondition `json:"stringMatch,omitempty"`
+
+	// CIDR is fulfilled if the value is an IP address within a network
+	CIDR *CIDRCondition `json:"cidr,omitempty"`
+
+	// Subject is fulfilled if the value is equal to the subject of the request
+	Subject *EqualsSubjectCondition `json:"subject,omitempty"`
+
+	// Equals is fulfilled if the value is equal to an arbitrary JSON value
+	Equals *EqualsCondition `json:"equals,omitempty"`
+
+	// StringPairsEqual is fulfilled if the value is a list of string pairs which are equal
+	StringPairsEqual *StringPairsEqualCondition `json:"stringPairsEqual,omitempty"`
+
+	// ResourceContains is fulfilled if the resource of the request contains the value
+	ResourceContains *ResourceContainsCondition `json:"resourceContains,omitempty"`
+
+	// Boolean is fulfilled if the value is equal to a boolean
+	Boolean *BooleanCondition `json:"boolean,omitempty"`
+
+	// Custom is passed to ORY Keto as is, for condition types not covered by the other fields
+	Custom *CustomCondition `json:"custom,omitempty"`
+}
+
+type StringEqualCondition struct {
+	Equals string `json:"equals"`
+}
+
+type StringMatchCondition struct {
+	Matches string `json:"matches"`
+}
+
+type CIDRCondition struct {
+	// CIDR is the network in CIDR notation, e.g. 10.0.0.0/8
+	CIDR string `json:"cidr"`
+}
+
+type EqualsSubjectCondition struct{}
+
+type EqualsCondition struct {
+	Equals apiextensionsv1beta1.JSON `json:"equals"`
+}
+
+type StringPairsEqualCondition struct{}
+
+type ResourceContainsCondition struct{}
+
+type BooleanCondition struct {
+	Value bool `json:"value"`
+}
+
+type CustomCondition struct {
+	// Type is the name the condition is registered with in ORY Keto
+	Type string `json:"type"`
+
+	// Options of the condition
+	// +kubebuilder:validation:Type=object
+	Options *runtime.RawExtension `json:"options,omitempty"`
+}
@@ -1,16 +1,16 @@
 package v1alpha1
 
 import (
-	"encoding/json"
-	"github.com/ory/keto-maester/keto"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // PolicySpec defines the desired state of Ory Keto Policy
 type PolicySpec struct {
-	// Define a way of rule matching(more info https://www.ory.sh/keto/docs/engines/acp-ory#pattern-matching-strategies)
-	PatternMatching PatternMatching `json:"pattern_matching"`
+	// Define a way of rule matching(more info https://www.ory.sh/keto/docs/engines/acp-ory#pattern-matching-strategies),
+	// defaults to exact
+	// +optional
+	PatternMatching PatternMatching `json:"pattern_matching,omitempty"`
 
 	// Description is the human-readable string that describes permission
 	Description string `json:"description,omitempty"`
@@ -21,15 +21,21 @@ type PolicySpec struct {
 	// Defines actions (ex, read, write, etc)
 	Actions []string `json:"actions"`
 
-	// Allow or deny access
-	Effect Action `json:"effect"`
+	// Allow or deny access, defaults to deny
+	// +optional
+	Effect Action `json:"effect,omitempty"`
 
 	// Resources defines object which you want to restrict access to
 	Resources []string `json:"resources"`
 
-	// Condition when to apply policy(see https://www.ory.sh/keto/docs/engines/acp-ory#conditions for details)
+	// Conditions when to apply policy, keyed by the name of the request context value they check
+	// (see https://www.ory.sh/keto/docs/engines/acp-ory#conditions for details)
+	Conditions map[string]PolicyCondition `json:"conditions,omitempty"`
+
+	// RawConditions are conditions in the format of ORY Keto, merged with Conditions.
+	// Deprecated: use Conditions, with its custom type for conditions it doesn't cover.
 	// +kubebuilder:validation:Type=object
-	Conditions *runtime.RawExtension `json:"condition,omitempty"`
+	RawConditions *runtime.RawExtension `json:"condition,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=exact;regex;glob
@@ -44,6 +50,9 @@ type PolicyStatus struct {
 	// ObservedGeneration represents the most recent generation observed by the daemon set controller.
 	ObservedGeneration  int64               `json:"observedGeneration,omitempty"`
 	ReconciliationError ReconciliationError `json:"reconciliationError,omitempty"`
+
+	// Conditions are the Ready, Synced and Degraded conditions of the policy
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // ReconciliationError represents an error that occurred during the reconciliation process
@@ -54,6 +63,10 @@ type ReconciliationError struct {
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Policy is the Schema for the keto policy API
 type Policy struct {
@@ -64,14 +77,6 @@ type Policy struct {
 	Status PolicyStatus `json:"status,omitempty"`
 }
 
-func (p *Policy) SetObservedGeneration(generation int64) {
-	p.Status.ObservedGeneration = generation
-}
-
-func (p *Policy) SetReconciliationError(err ReconciliationError) {
-	p.Status.ReconciliationError = err
-}
-
 // +kubebuilder:object:root=true
 
 //PolicyList contains a list of Policy
@@ -84,18 +89,3 @@ type PolicyList struct {
 func init() {
 	SchemeBuilder.Register(&Policy{}, &PolicyList{})
 }
-
-// ToPolicyJSON converts an Policy into a PolicyJSON object that represents an Policy digestible by ORY Keto
-func (p *Policy) ToPolicyJSON() *keto.PolicyJSON {
-	conditions, _ := json.Marshal(p.Spec.Conditions)
-
-	return &keto.PolicyJSON{
-		Id:          GenerateId(p),
-		Actions:     p.Spec.Actions,
-		Conditions:  conditions,
-		Description: p.Spec.Description,
-		Effect:      string(p.Spec.Effect),
-		Resources:   p.Spec.Resources,
-		Subjects:    p.Spec.Subjects,
-	}
-}
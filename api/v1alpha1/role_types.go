@@ -1,29 +1,52 @@
 package v1alpha1
 
 import (
-	"fmt"
-	"github.com/ory/keto-maester/keto"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func GenerateId(named metav1.Object) string {
-	return fmt.Sprintf("%s:%s", named.GetNamespace(), named.GetName())
-}
-
 // PolicyStatus defines the observed state of Policy
 type RoleStatus struct {
 	// ObservedGeneration represents the most recent generation observed by the daemon set controller.
 	ObservedGeneration  int64               `json:"observedGeneration,omitempty"`
 	ReconciliationError ReconciliationError `json:"reconciliationError,omitempty"`
+
+	// ManagedMembers are the members the controller added to the role in Keto during the last reconciliation
+	ManagedMembers []string `json:"managedMembers,omitempty"`
+
+	// Conditions are the Ready, Synced and Degraded conditions of the role
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 type RoleSpec struct {
+	// Define a way of rule matching of the policies the role is used in
+	// (more info https://www.ory.sh/keto/docs/engines/acp-ory#pattern-matching-strategies), defaults to exact
+	// +optional
+	PatternMatching PatternMatching `json:"pattern_matching,omitempty"`
+
 	// Members of role
 	Members []string `json:"members,omitempty"`
+
+	// Defines how members are applied to the role in Keto. With "replace" the members of the role are exactly
+	// the ones listed above, with "merge" only members listed here are added and removed, leaving members
+	// added by other systems in place
+	// +optional
+	MembershipMode MembershipMode `json:"membershipMode,omitempty"`
 }
 
+// +kubebuilder:validation:Enum=replace;merge
+type MembershipMode string
+
+const (
+	MembershipReplace MembershipMode = "replace"
+	MembershipMerge   MembershipMode = "merge"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 type Role struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -33,14 +56,6 @@ type Role struct {
 	Status RoleStatus `json:"status,omitempty"`
 }
 
-func (r *Role) SetObservedGeneration(generation int64) {
-	r.Status.ObservedGeneration = generation
-}
-
-func (r *Role) SetReconciliationError(err ReconciliationError) {
-	r.Status.ReconciliationError = err
-}
-
 // +kubebuilder:object:root=true
 
 //RoleList contains a list of Role
@@ -53,10 +68,3 @@ type RoleList struct {
 func init() {
 	SchemeBuilder.Register(&Role{}, &RoleList{})
 }
-
-func (r *Role) ToRoleJSON() *keto.Role {
-	return &keto.Role{
-		Id:      GenerateId(r),
-		Members: r.Spec.Members,
-	}
-}
@@ -8,13 +8,110 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BooleanCondition) DeepCopyInto(out *BooleanCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BooleanCondition.
+func (in *BooleanCondition) DeepCopy() *BooleanCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(BooleanCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CIDRCondition) DeepCopyInto(out *CIDRCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CIDRCondition.
+func (in *CIDRCondition) DeepCopy() *CIDRCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(CIDRCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomCondition) DeepCopyInto(out *CustomCondition) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomCondition.
+func (in *CustomCondition) DeepCopy() *CustomCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EqualsCondition) DeepCopyInto(out *EqualsCondition) {
+	*out = *in
+	in.Equals.DeepCopyInto(&out.Equals)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EqualsCondition.
+func (in *EqualsCondition) DeepCopy() *EqualsCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(EqualsCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EqualsSubjectCondition) DeepCopyInto(out *EqualsSubjectCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EqualsSubjectCondition.
+func (in *EqualsSubjectCondition) DeepCopy() *EqualsSubjectCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(EqualsSubjectCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Policy) DeepCopyInto(out *Policy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Policy.
@@ -35,6 +132,66 @@ func (in *Policy) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyCondition) DeepCopyInto(out *PolicyCondition) {
+	*out = *in
+	if in.StringEqual != nil {
+		in, out := &in.StringEqual, &out.StringEqual
+		*out = new(StringEqualCondition)
+		**out = **in
+	}
+	if in.StringMatch != nil {
+		in, out := &in.StringMatch, &out.StringMatch
+		*out = new(StringMatchCondition)
+		**out = **in
+	}
+	if in.CIDR != nil {
+		in, out := &in.CIDR, &out.CIDR
+		*out = new(CIDRCondition)
+		**out = **in
+	}
+	if in.Subject != nil {
+		in, out := &in.Subject, &out.Subject
+		*out = new(EqualsSubjectCondition)
+		**out = **in
+	}
+	if in.Equals != nil {
+		in, out := &in.Equals, &out.Equals
+		*out = new(EqualsCondition)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StringPairsEqual != nil {
+		in, out := &in.StringPairsEqual, &out.StringPairsEqual
+		*out = new(StringPairsEqualCondition)
+		**out = **in
+	}
+	if in.ResourceContains != nil {
+		in, out := &in.ResourceContains, &out.ResourceContains
+		*out = new(ResourceContainsCondition)
+		**out = **in
+	}
+	if in.Boolean != nil {
+		in, out := &in.Boolean, &out.Boolean
+		*out = new(BooleanCondition)
+		**out = **in
+	}
+	if in.Custom != nil {
+		in, out := &in.Custom, &out.Custom
+		*out = new(CustomCondition)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyCondition.
+func (in *PolicyCondition) DeepCopy() *PolicyCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PolicyList) DeepCopyInto(out *PolicyList) {
 	*out = *in
@@ -87,6 +244,13 @@ func (in *PolicySpec) DeepCopyInto(out *PolicySpec) {
 	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
+		*out = make(map[string]PolicyCondition, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.RawConditions != nil {
+		in, out := &in.RawConditions, &out.RawConditions
 		*out = new(runtime.RawExtension)
 		(*in).DeepCopyInto(*out)
 	}
@@ -106,6 +270,13 @@ func (in *PolicySpec) DeepCopy() *PolicySpec {
 func (in *PolicyStatus) DeepCopyInto(out *PolicyStatus) {
 	*out = *in
 	out.ReconciliationError = in.ReconciliationError
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyStatus.
@@ -133,13 +304,28 @@ func (in *ReconciliationError) DeepCopy() *ReconciliationError {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceContainsCondition) DeepCopyInto(out *ResourceContainsCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceContainsCondition.
+func (in *ResourceContainsCondition) DeepCopy() *ResourceContainsCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceContainsCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Role) DeepCopyInto(out *Role) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Role.
@@ -216,6 +402,18 @@ func (in *RoleSpec) DeepCopy() *RoleSpec {
 func (in *RoleStatus) DeepCopyInto(out *RoleStatus) {
 	*out = *in
 	out.ReconciliationError = in.ReconciliationError
+	if in.ManagedMembers != nil {
+		in, out := &in.ManagedMembers, &out.ManagedMembers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoleStatus.
@@ -227,3 +425,48 @@ func (in *RoleStatus) DeepCopy() *RoleStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringEqualCondition) DeepCopyInto(out *StringEqualCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringEqualCondition.
+func (in *StringEqualCondition) DeepCopy() *StringEqualCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(StringEqualCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringMatchCondition) DeepCopyInto(out *StringMatchCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringMatchCondition.
+func (in *StringMatchCondition) DeepCopy() *StringMatchCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(StringMatchCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringPairsEqualCondition) DeepCopyInto(out *StringPairsEqualCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringPairsEqualCondition.
+func (in *StringPairsEqualCondition) DeepCopy() *StringPairsEqualCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(StringPairsEqualCondition)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,109 @@
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a status condition of Policy and Role
+type ConditionType string
+
+const (
+	// ConditionReady is true when the object is applied in Keto and nothing prevents keeping it in sync
+	ConditionReady ConditionType = "Ready"
+	// ConditionSynced is true when the last reconciliation applied the object to Keto
+	ConditionSynced ConditionType = "Synced"
+	// ConditionDegraded is true when the object can't be reconciled, its reason and message tell why
+	ConditionDegraded ConditionType = "Degraded"
+	// ConditionResolved is false when references to other objects can't be resolved, e.g. a policy refers to a missing role
+	ConditionResolved ConditionType = "Resolved"
+	// ConditionPaused is true while nothing is written to Keto for the object because of the paused annotation
+	ConditionPaused ConditionType = "Paused"
+)
+
+// Reasons set on the conditions of Policy and Role
+const (
+	ReasonSynced              = "Synced"
+	ReasonKetoRejected        = "KetoRejected"
+	ReasonKetoError           = "KetoError"
+	ReasonKetoUnavailable     = "KetoUnavailable"
+	ReasonReconcileError      = "ReconcileError"
+	ReasonKetoIDConflict      = "KetoIDConflict"
+	ReasonResolved            = "Resolved"
+	ReasonRoleNotFound        = "RoleNotFound"
+	ReasonRoleNotSynced       = "RoleNotSynced"
+	ReasonRoleFlavourMismatch = "RoleFlavourMismatch"
+	ReasonMemberRefForbidden  = "MemberRefForbidden"
+	ReasonAdopted             = "Adopted"
+	ReasonPaused              = "Paused"
+	ReasonResumed             = "Resumed"
+)
+
+// +kubebuilder:validation:Enum=True;False;Unknown
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// Condition describes one aspect of the state of an object, following the shape of Kubernetes conditions
+type Condition struct {
+	// Type of the condition, one of Ready, Synced, Degraded, Resolved or Paused
+	Type ConditionType `json:"type"`
+
+	// Status of the condition, one of True, False or Unknown
+	Status ConditionStatus `json:"status"`
+
+	// ObservedGeneration is the generation of the object the condition was set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastTransitionTime is the last time the status of the condition changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Reason is a machine-readable CamelCase explanation of the status
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human-readable explanation of the status
+	Message string `json:"message,omitempty"`
+}
+
+// SetCondition adds condition to conditions or replaces the one of the same type.
+// The last transition time is kept as long as the status doesn't change.
+func SetCondition(conditions *[]Condition, condition Condition) {
+	existing := FindCondition(*conditions, condition.Type)
+	if existing == nil {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		*conditions = append(*conditions, condition)
+		return
+	}
+
+	if existing.Status != condition.Status {
+		existing.Status = condition.Status
+		existing.LastTransitionTime = condition.LastTransitionTime
+		if existing.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.Now()
+		}
+	}
+	existing.ObservedGeneration = condition.ObservedGeneration
+	existing.Reason = condition.Reason
+	existing.Message = condition.Message
+}
+
+// FindCondition returns the condition of the given type, or nil if there is none
+func FindCondition(conditions []Condition, conditionType ConditionType) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue reports whether the condition of the given type is present and true
+func IsConditionTrue(conditions []Condition, conditionType ConditionType) bool {
+	c := FindCondition(conditions, conditionType)
+	return c != nil && c.Status == ConditionTrue
+}
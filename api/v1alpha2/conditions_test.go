@@ -0,0 +1,59 @@
+package v1alpha2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetCondition(t *testing.T) {
+
+	assert := assert.New(t)
+
+	transition := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+	t.Run("case/keeping transition time while status is unchanged", func(t *testing.T) {
+
+		//given
+		conditions := []Condition{{Type: ConditionSynced, Status: ConditionTrue, LastTransitionTime: transition, Reason: ReasonSynced}}
+
+		//when
+		SetCondition(&conditions, Condition{Type: ConditionSynced, Status: ConditionTrue, ObservedGeneration: 2, Reason: ReasonSynced})
+
+		//then
+		assert.Len(conditions, 1)
+		assert.Equal(transition, conditions[0].LastTransitionTime)
+		assert.Equal(int64(2), conditions[0].ObservedGeneration)
+	})
+
+	t.Run("case/updating transition time when status changes", func(t *testing.T) {
+
+		//given
+		conditions := []Condition{{Type: ConditionSynced, Status: ConditionTrue, LastTransitionTime: transition, Reason: ReasonSynced}}
+
+		//when
+		SetCondition(&conditions, Condition{Type: ConditionSynced, Status: ConditionFalse, Reason: ReasonKetoRejected, Message: "invalid"})
+
+		//then
+		assert.Len(conditions, 1)
+		assert.True(conditions[0].LastTransitionTime.After(transition.Time))
+		assert.Equal(ReasonKetoRejected, conditions[0].Reason)
+		assert.False(IsConditionTrue(conditions, ConditionSynced))
+	})
+
+	t.Run("case/adding missing condition", func(t *testing.T) {
+
+		//given
+		var conditions []Condition
+
+		//when
+		SetCondition(&conditions, Condition{Type: ConditionReady, Status: ConditionTrue})
+
+		//then
+		assert.True(IsConditionTrue(conditions, ConditionReady))
+		assert.False(conditions[0].LastTransitionTime.IsZero())
+		assert.Nil(FindCondition(conditions, ConditionDegraded))
+	})
+}
@@ -0,0 +1,62 @@
+package v1alpha2
+
+import (
+	"sort"
+	"strings"
+)
+
+// Values of optional fields which are left empty
+const (
+	DefaultFlavour        Flavour = "exact"
+	DefaultEffect                 = EffectDeny
+	DefaultMembershipMode         = MembershipReplace
+)
+
+// SetDefaults fills in the optional fields of the policy and normalizes its subjects, actions and resources,
+// so that reordering or repeating them doesn't change the policy
+func (p *Policy) SetDefaults() {
+	if p.Spec.Flavour == "" {
+		p.Spec.Flavour = DefaultFlavour
+	}
+	if p.Spec.Effect == "" {
+		p.Spec.Effect = DefaultEffect
+	}
+
+	p.Spec.Subjects = normalizeStrings(p.Spec.Subjects, true)
+	p.Spec.Actions = normalizeStrings(p.Spec.Actions, true)
+	p.Spec.Resources = normalizeStrings(p.Spec.Resources, true)
+}
+
+// SetDefaults fills in the optional fields of the role and sorts its members. Repeated members are kept
+// so that validation can point them out.
+func (r *Role) SetDefaults() {
+	if r.Spec.Flavour == "" {
+		r.Spec.Flavour = DefaultFlavour
+	}
+	if r.Spec.MembershipMode == "" {
+		r.Spec.MembershipMode = DefaultMembershipMode
+	}
+
+	r.Spec.Members = normalizeStrings(r.Spec.Members, false)
+}
+
+// normalizeStrings trims and sorts items, optionally dropping repeated ones
+func normalizeStrings(items []string, dedupe bool) []string {
+	if items == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(items))
+	seen := map[string]bool{}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if dedupe && seen[item] {
+			continue
+		}
+		seen[item] = true
+		normalized = append(normalized, item)
+	}
+
+	sort.Strings(normalized)
+	return normalized
+}
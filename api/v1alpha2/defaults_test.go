@@ -0,0 +1,40 @@
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicySetDefaults(t *testing.T) {
+
+	//given
+	p := &Policy{Spec: PolicySpec{
+		Subjects:  []string{"users:bob", " users:alice", "users:bob"},
+		Actions:   []string{"write", "read"},
+		Resources: []string{"blog"},
+	}}
+
+	//when
+	p.SetDefaults()
+
+	//then
+	assert.Equal(t, DefaultFlavour, p.Spec.Flavour)
+	assert.Equal(t, DefaultEffect, p.Spec.Effect)
+	assert.Equal(t, []string{"users:alice", "users:bob"}, p.Spec.Subjects)
+	assert.Equal(t, []string{"read", "write"}, p.Spec.Actions)
+}
+
+func TestRoleSetDefaults(t *testing.T) {
+
+	//given
+	r := &Role{Spec: RoleSpec{Members: []string{"bob", "alice", "bob"}, MembershipMode: MembershipMerge}}
+
+	//when
+	r.SetDefaults()
+
+	//then
+	assert.Equal(t, DefaultFlavour, r.Spec.Flavour)
+	assert.Equal(t, MembershipMerge, r.Spec.MembershipMode)
+	assert.Equal(t, []string{"alice", "bob", "bob"}, r.Spec.Members)
+}
@@ -0,0 +1,232 @@
+package v1alpha2
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ory/keto-maester/keto"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PolicyCondition is one of the conditions of ORY Keto (see https://www.ory.sh/keto/docs/engines/acp-ory#conditions),
+// exactly one of its fields must be set
+type PolicyCondition struct {
+	// StringEqual is fulfilled if the value is equal to a string
+	StringEqual *StringEqualCondition `json:"stringEqual,omitempty"`
+
+	// StringMatch is fulfilled if the value matches a regular expression
+	StringMatch *StringMatchCondition `json:"stringMatch,omitempty"`
+
+	// CIDR is fulfilled if the value is an IP address within a network
+	CIDR *CIDRCondition `json:"cidr,omitempty"`
+
+	// Subject is fulfilled if the value is equal to the subject of the request
+	Subject *EqualsSubjectCondition `json:"subject,omitempty"`
+
+	// Equals is fulfilled if the value is equal to an arbitrary JSON value
+	Equals *EqualsCondition `json:"equals,omitempty"`
+
+	// StringPairsEqual is fulfilled if the value is a list of string pairs which are equal
+	StringPairsEqual *StringPairsEqualCondition `json:"stringPairsEqual,omitempty"`
+
+	// ResourceContains is fulfilled if the resource of the request contains the value
+	ResourceContains *ResourceContainsCondition `json:"resourceContains,omitempty"`
+
+	// Boolean is fulfilled if the value is equal to a boolean
+	Boolean *BooleanCondition `json:"boolean,omitempty"`
+
+	// Custom is passed to ORY Keto as is, for condition types not covered by the other fields
+	Custom *CustomCondition `json:"custom,omitempty"`
+}
+
+type StringEqualCondition struct {
+	Equals string `json:"equals"`
+}
+
+type StringMatchCondition struct {
+	Matches string `json:"matches"`
+}
+
+type CIDRCondition struct {
+	// CIDR is the network in CIDR notation, e.g. 10.0.0.0/8
+	CIDR string `json:"cidr"`
+}
+
+type EqualsSubjectCondition struct{}
+
+type EqualsCondition struct {
+	Equals apiextensionsv1beta1.JSON `json:"equals"`
+}
+
+type StringPairsEqualCondition struct{}
+
+type ResourceContainsCondition struct{}
+
+type BooleanCondition struct {
+	Value bool `json:"value"`
+}
+
+type CustomCondition struct {
+	// Type is the name the condition is registered with in ORY Keto
+	Type string `json:"type"`
+
+	// Options of the condition
+	// +kubebuilder:validation:Type=object
+	Options *runtime.RawExtension `json:"options,omitempty"`
+}
+
+// ToKeto converts the condition into the form ORY Keto stores it in
+func (c *PolicyCondition) ToKeto() (keto.Condition, error) {
+	if variants := c.variants(); variants != 1 {
+		return keto.Condition{}, fmt.Errorf("exactly one condition type must be set, got %d", variants)
+	}
+
+	var converted keto.Condition
+	add := func(conditionType string, options interface{}) error {
+		raw, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+		converted.Type = conditionType
+		return json.Unmarshal(raw, &converted.Options)
+	}
+
+	var err error
+	switch {
+	case c.StringEqual != nil:
+		err = add(keto.StringEqualCondition, c.StringEqual)
+	case c.StringMatch != nil:
+		err = add(keto.StringMatchCondition, c.StringMatch)
+	case c.CIDR != nil:
+		err = add(keto.CIDRCondition, c.CIDR)
+	case c.Subject != nil:
+		err = add(keto.EqualsSubjectCondition, c.Subject)
+	case c.Equals != nil:
+		err = add(keto.EqualsCondition, map[string]json.RawMessage{"equals": c.Equals.Equals.Raw})
+	case c.StringPairsEqual != nil:
+		err = add(keto.StringPairsEqualCondition, c.StringPairsEqual)
+	case c.ResourceContains != nil:
+		err = add(keto.ResourceContainsCondition, c.ResourceContains)
+	case c.Boolean != nil:
+		err = add(keto.BooleanCondition, c.Boolean)
+	case c.Custom != nil:
+		options := json.RawMessage("{}")
+		if c.Custom.Options != nil && len(c.Custom.Options.Raw) > 0 {
+			options = c.Custom.Options.Raw
+		}
+		err = add(c.Custom.Type, options)
+	}
+	return converted, err
+}
+
+// variants counts the condition types set
+func (c *PolicyCondition) variants() int {
+	n := 0
+	for _, set := range []bool{
+		c.StringEqual != nil, c.StringMatch != nil, c.CIDR != nil, c.Subject != nil, c.Equals != nil,
+		c.StringPairsEqual != nil, c.ResourceContains != nil, c.Boolean != nil, c.Custom != nil,
+	} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// PolicyConditionFromKeto converts a condition stored by ORY Keto, conditions of unknown types become custom ones
+func PolicyConditionFromKeto(c keto.Condition) (PolicyCondition, error) {
+	options, err := json.Marshal(c.Options)
+	if err != nil {
+		return PolicyCondition{}, err
+	}
+	if len(c.Options) == 0 {
+		options = []byte("{}")
+	}
+
+	var converted PolicyCondition
+	var into interface{}
+	switch c.Type {
+	case keto.StringEqualCondition:
+		converted.StringEqual = &StringEqualCondition{}
+		into = converted.StringEqual
+	case keto.StringMatchCondition:
+		converted.StringMatch = &StringMatchCondition{}
+		into = converted.StringMatch
+	case keto.CIDRCondition:
+		converted.CIDR = &CIDRCondition{}
+		into = converted.CIDR
+	case keto.EqualsSubjectCondition:
+		converted.Subject = &EqualsSubjectCondition{}
+	case keto.EqualsCondition:
+		converted.Equals = &EqualsCondition{Equals: apiextensionsv1beta1.JSON{Raw: c.Options["equals"]}}
+	case keto.StringPairsEqualCondition:
+		converted.StringPairsEqual = &StringPairsEqualCondition{}
+	case keto.ResourceContainsCondition:
+		converted.ResourceContains = &ResourceContainsCondition{}
+	case keto.BooleanCondition:
+		converted.Boolean = &BooleanCondition{}
+		into = converted.Boolean
+	default:
+		converted.Custom = &CustomCondition{Type: c.Type, Options: &runtime.RawExtension{Raw: options}}
+	}
+
+	if into != nil {
+		if err := json.Unmarshal(options, into); err != nil {
+			return PolicyCondition{}, fmt.Errorf("invalid options of %s: %s", c.Type, err)
+		}
+	}
+	return converted, nil
+}
+
+// PolicyConditionsFromKeto converts the conditions of a policy stored by ORY Keto
+func PolicyConditionsFromKeto(conditions json.RawMessage) (map[string]PolicyCondition, error) {
+	if len(conditions) == 0 || string(conditions) == "null" {
+		return nil, nil
+	}
+
+	var parsed map[string]keto.Condition
+	if err := json.Unmarshal(conditions, &parsed); err != nil {
+		return nil, err
+	}
+	if len(parsed) == 0 {
+		return nil, nil
+	}
+
+	converted := make(map[string]PolicyCondition, len(parsed))
+	for name, c := range parsed {
+		condition, err := PolicyConditionFromKeto(c)
+		if err != nil {
+			return nil, fmt.Errorf("condition %q: %s", name, err)
+		}
+		converted[name] = condition
+	}
+	return converted, nil
+}
+
+// ketoConditions converts the conditions into the JSON stored by ORY Keto, it returns nil if the policy
+// has no conditions
+func (p *Policy) ketoConditions() (json.RawMessage, error) {
+	merged := map[string]keto.Condition{}
+
+	names := make([]string, 0, len(p.Spec.Conditions))
+	for name := range p.Spec.Conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		condition := p.Spec.Conditions[name]
+		converted, err := condition.ToKeto()
+		if err != nil {
+			return nil, fmt.Errorf("condition %q: %s", name, err)
+		}
+		merged[name] = converted
+	}
+
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(merged)
+}
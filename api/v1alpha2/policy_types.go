@@ -0,0 +1,176 @@
+package v1alpha2
+
+import (
+	"github.com/ory/keto-maester/keto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolicySpec defines the desired state of Ory Keto Policy
+type PolicySpec struct {
+	// KetoID is the ID of the policy in ORY Keto, defaults to namespace:name
+	// +optional
+	KetoID string `json:"ketoId,omitempty"`
+
+	// Flavour is the way subjects, actions and resources are matched, defaults to exact
+	// (more info https://www.ory.sh/keto/docs/engines/acp-ory#pattern-matching-strategies)
+	// +optional
+	Flavour Flavour `json:"flavour,omitempty"`
+
+	// Description is the human-readable string that describes permission
+	Description string `json:"description,omitempty"`
+
+	// Subjects for whom policies will applied to(for users: users:${username}, for groups: ${scope}:${group_name})
+	Subjects []string `json:"subjects,omitempty"`
+
+	// SubjectRefs add the IDs in ORY Keto of the referenced roles as subjects
+	// +optional
+	SubjectRefs []RoleRef `json:"subjectRefs,omitempty"`
+
+	// Defines actions (ex, read, write, etc)
+	Actions []string `json:"actions"`
+
+	// Effect of the policy, allow or deny access, defaults to deny
+	// +optional
+	Effect Effect `json:"effect,omitempty"`
+
+	// Resources defines object which you want to restrict access to
+	Resources []string `json:"resources"`
+
+	// Conditions when to apply policy, keyed by the name of the request context value they check
+	// (see https://www.ory.sh/keto/docs/engines/acp-ory#conditions for details)
+	Conditions map[string]PolicyCondition `json:"conditions,omitempty"`
+
+	// DeletionPolicy tells whether the policy is deleted from ORY Keto or kept there when the object is
+	// deleted, defaults to the deletion policy of the controller
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// RoleRef refers to a Role by name
+type RoleRef struct {
+	// Name of the role
+	Name string `json:"name"`
+
+	// Namespace of the role, defaults to the namespace of the referencing object
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=exact;regex;glob
+// more info https://www.ory.sh/keto/docs/engines/acp-ory#pattern-matching-strategies
+type Flavour string
+
+// +kubebuilder:validation:Enum=allow;deny
+type Effect string
+
+// +kubebuilder:validation:Enum=Delete;Retain
+type DeletionPolicy string
+
+const (
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+// PolicyStatus defines the observed state of Policy
+type PolicyStatus struct {
+	// ObservedGeneration is the most recent generation the conditions were set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// KetoID is the ID the policy was last applied with in ORY Keto
+	KetoID string `json:"ketoId,omitempty"`
+
+	// Flavour is the pattern matching flavour the policy was last applied with in ORY Keto
+	Flavour Flavour `json:"flavour,omitempty"`
+
+	// ResolvedSubjects are the IDs in ORY Keto the subject references resolved to during the last reconciliation
+	ResolvedSubjects []string `json:"resolvedSubjects,omitempty"`
+
+	// Conditions are the Ready, Synced, Degraded, Resolved and Paused conditions of the policy
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Policy is the Schema for the keto policy API
+type Policy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolicySpec   `json:"spec,omitempty"`
+	Status PolicyStatus `json:"status,omitempty"`
+}
+
+// Hub marks v1alpha2 as the version other versions of Policy are converted through
+func (*Policy) Hub() {}
+
+func (p *Policy) SetObservedGeneration(generation int64) {
+	p.Status.ObservedGeneration = generation
+}
+
+func (p *Policy) SetCondition(condition Condition) {
+	SetCondition(&p.Status.Conditions, condition)
+}
+
+// KetoID returns the ID of the policy in ORY Keto
+func (p *Policy) KetoID() string {
+	if p.Spec.KetoID != "" {
+		return p.Spec.KetoID
+	}
+	return GenerateId(p)
+}
+
+// KetoKey identifies the policy in ORY Keto, policies with the same key overwrite each other
+func (p *Policy) KetoKey() string {
+	return KetoKey(p.Spec.Flavour, p.KetoID())
+}
+
+// Subjects returns the subjects of the policy in ORY Keto, the ones listed in the spec and the ones
+// resolved from its subject references
+func (p *Policy) Subjects() []string {
+	if len(p.Status.ResolvedSubjects) == 0 {
+		return p.Spec.Subjects
+	}
+	return normalizeStrings(append(append([]string{}, p.Spec.Subjects...), p.Status.ResolvedSubjects...), true)
+}
+
+// +kubebuilder:object:root=true
+
+//PolicyList contains a list of Policy
+type PolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Policy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Policy{}, &PolicyList{})
+}
+
+// ToPolicyJSON converts an Policy into a PolicyJSON object that represents an Policy digestible by ORY Keto
+func (p *Policy) ToPolicyJSON() (*keto.PolicyJSON, error) {
+	conditions, err := p.ketoConditions()
+	if err != nil {
+		return nil, err
+	}
+
+	return &keto.PolicyJSON{
+		Id:          p.KetoID(),
+		Actions:     p.Spec.Actions,
+		Conditions:  conditions,
+		Description: p.Spec.Description,
+		Effect:      string(p.Spec.Effect),
+		Resources:   p.Spec.Resources,
+		Subjects:    p.Subjects(),
+	}, nil
+}
@@ -0,0 +1,120 @@
+package v1alpha2
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestToPolicyJSON(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		spec       PolicySpec
+		conditions string
+		fails      bool
+	}{
+		"without conditions": {},
+		"with typed conditions": {
+			spec: PolicySpec{Conditions: map[string]PolicyCondition{
+				"owner":  {Subject: &EqualsSubjectCondition{}},
+				"ip":     {CIDR: &CIDRCondition{CIDR: "10.0.0.0/8"}},
+				"level":  {Equals: &EqualsCondition{Equals: apiextensionsv1beta1.JSON{Raw: []byte(`3`)}}},
+				"tenant": {Custom: &CustomCondition{Type: "TenantCondition", Options: &runtime.RawExtension{Raw: []byte(`{"strict":true}`)}}},
+			}},
+			conditions: `{"ip":{"type":"CIDRCondition","options":{"cidr":"10.0.0.0/8"}},"level":{"type":"EqualsCondition","options":{"equals":3}},"owner":{"type":"EqualsSubjectCondition","options":{}},"tenant":{"type":"TenantCondition","options":{"strict":true}}}`,
+		},
+		"with ambiguous condition": {
+			spec:  PolicySpec{Conditions: map[string]PolicyCondition{"env": {StringEqual: &StringEqualCondition{Equals: "dev"}, StringMatch: &StringMatchCondition{Matches: "dev"}}}},
+			fails: true,
+		},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			p := &Policy{Spec: tc.spec}
+
+			//when
+			policyJSON, err := p.ToPolicyJSON()
+
+			//then
+			if tc.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tc.conditions == "" {
+				assert.Nil(t, policyJSON.Conditions)
+			} else {
+				assert.JSONEq(t, tc.conditions, string(policyJSON.Conditions))
+			}
+		})
+	}
+}
+
+func TestPolicyKetoKey(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		spec PolicySpec
+		key  string
+	}{
+		"generated id":         {spec: PolicySpec{}, key: "exact/payments:read"},
+		"explicit id":          {spec: PolicySpec{KetoID: "policies:payments:read"}, key: "exact/policies:payments:read"},
+		"explicit id in regex": {spec: PolicySpec{KetoID: "policies:payments:read", Flavour: "regex"}, key: "regex/policies:payments:read"},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			p := &Policy{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "read"}, Spec: tc.spec}
+
+			//when
+			key := p.KetoKey()
+
+			//then
+			assert.Equal(t, tc.key, key)
+		})
+	}
+}
+
+func TestPolicySubjects(t *testing.T) {
+
+	//given
+	p := &Policy{
+		Spec:   PolicySpec{Subjects: []string{"users:bob"}, SubjectRefs: []RoleRef{{Name: "admins"}}},
+		Status: PolicyStatus{ResolvedSubjects: []string{"payments:admins"}},
+	}
+
+	//when
+	policyJSON, err := p.ToPolicyJSON()
+
+	//then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments:admins", "users:bob"}, policyJSON.Subjects)
+}
+
+func TestPolicyConditionsFromKeto(t *testing.T) {
+
+	//given
+	stored := `{"admin":{"type":"BooleanCondition","options":{"value":true}},"level":{"type":"EqualsCondition","options":{"equals":[1,2]}},"owner":{"type":"EqualsSubjectCondition","options":{}},"tenant":{"type":"TenantCondition","options":{"strict":true}}}`
+
+	//when
+	conditions, err := PolicyConditionsFromKeto([]byte(stored))
+
+	//then
+	require.NoError(t, err)
+	assert.Equal(t, &BooleanCondition{Value: true}, conditions["admin"].Boolean)
+	assert.NotNil(t, conditions["owner"].Subject)
+	assert.Equal(t, "TenantCondition", conditions["tenant"].Custom.Type)
+
+	//when
+	p := &Policy{Spec: PolicySpec{Conditions: conditions}}
+	policyJSON, err := p.ToPolicyJSON()
+
+	//then
+	require.NoError(t, err)
+	assert.JSONEq(t, stored, string(policyJSON.Conditions))
+}
@@ -0,0 +1,150 @@
+package v1alpha2
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Defaults of the kind of member objects
+const (
+	DefaultMemberKind       = "ServiceAccount"
+	DefaultMemberAPIVersion = "v1"
+
+	// ServiceAccountSubjectTemplate renders the user name Kubernetes authenticates service accounts as
+	ServiceAccountSubjectTemplate = "system:serviceaccount:{{.Namespace}}:{{.Name}}"
+)
+
+// MemberKind selects the kind of the objects members are resolved from and how they become members
+type MemberKind struct {
+	// APIVersion of the objects, defaults to v1 for ServiceAccount and to keto.ory.sh/v1alpha2 for Role
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the objects, defaults to ServiceAccount. Role refers to roles managed by this controller.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// SubjectTemplate renders the subject of an object with text/template, from its .APIVersion, .Kind,
+	// .Namespace, .Name, .Labels and .Annotations. Service accounts default to
+	// system:serviceaccount:{{.Namespace}}:{{.Name}}, other kinds but Role require it.
+	// +optional
+	SubjectTemplate string `json:"subjectTemplate,omitempty"`
+
+	// RoleMembership is how referenced roles become members: with "transitive" the members of the role are
+	// added, with "nested" the role itself is added by its ID in ORY Keto. Defaults to transitive.
+	// +optional
+	RoleMembership RoleMembership `json:"roleMembership,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=transitive;nested
+type RoleMembership string
+
+const (
+	RoleMembershipTransitive RoleMembership = "transitive"
+	RoleMembershipNested     RoleMembership = "nested"
+)
+
+// MemberRef refers to a single object whose subject is a member of the role
+type MemberRef struct {
+	MemberKind `json:",inline"`
+
+	// Name of the object
+	Name string `json:"name"`
+
+	// Namespace of the object, defaults to the namespace of the role. Other namespaces must be allowed by the
+	// controller.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// MemberSelector selects objects in the namespace of the role by label, their subjects are members of the role
+type MemberSelector struct {
+	MemberKind `json:",inline"`
+
+	metav1.LabelSelector `json:",inline"`
+}
+
+// GroupVersionKind returns the kind of the objects, with the defaults applied
+func (k MemberKind) GroupVersionKind() (schema.GroupVersionKind, error) {
+	kind := k.Kind
+	if kind == "" {
+		kind = DefaultMemberKind
+	}
+
+	apiVersion := k.APIVersion
+	switch {
+	case apiVersion != "":
+	case kind == DefaultMemberKind:
+		apiVersion = DefaultMemberAPIVersion
+	case kind == "Role":
+		apiVersion = GroupVersion.String()
+	default:
+		return schema.GroupVersionKind{}, fmt.Errorf("the apiVersion of %s is required", kind)
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return gv.WithKind(kind), nil
+}
+
+// IsRole tells whether the objects are roles managed by this controller
+func (k MemberKind) IsRole() bool {
+	gvk, err := k.GroupVersionKind()
+	return err == nil && gvk.Group == GroupVersion.Group && gvk.Kind == "Role"
+}
+
+// Template parses the subject template, with the default of the kind applied
+func (k MemberKind) Template() (*template.Template, error) {
+	text := k.SubjectTemplate
+	if text == "" {
+		gvk, err := k.GroupVersionKind()
+		if err != nil {
+			return nil, err
+		}
+		if gvk.Group != "" || gvk.Kind != DefaultMemberKind {
+			return nil, fmt.Errorf("the subjectTemplate of %s is required", gvk.Kind)
+		}
+		text = ServiceAccountSubjectTemplate
+	}
+	return template.New("subject").Option("missingkey=error").Parse(text)
+}
+
+// MemberObject is the data subject templates are rendered with, only the metadata of the object so that roles
+// can't copy its content into ORY Keto
+// +kubebuilder:object:generate=false
+type MemberObject struct {
+	APIVersion  string
+	Kind        string
+	Namespace   string
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// RenderSubject renders the subject of obj with tmpl
+func RenderSubject(tmpl *template.Template, obj MemberObject) (string, error) {
+	var subject bytes.Buffer
+	if err := tmpl.Execute(&subject, obj); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(subject.String()) == "" {
+		return "", fmt.Errorf("the subject of %s %s/%s is empty", obj.Kind, obj.Namespace, obj.Name)
+	}
+	return strings.TrimSpace(subject.String()), nil
+}
+
+// Members returns the members of the role in ORY Keto, the ones listed in the spec and the ones resolved
+// from its member references and selector
+func (r *Role) Members() []string {
+	if len(r.Status.ResolvedMembers) == 0 {
+		return r.Spec.Members
+	}
+	return normalizeStrings(append(append([]string{}, r.Spec.Members...), r.Status.ResolvedMembers...), true)
+}
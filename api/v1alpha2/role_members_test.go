@@ -0,0 +1,70 @@
+package v1alpha2
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSubject(t *testing.T) {
+
+	obj := MemberObject{Namespace: "payments", Name: "api", Labels: map[string]string{"team": "billing"}}
+
+	for d, tc := range map[string]struct {
+		kind    MemberKind
+		subject string
+		fails   bool
+	}{
+		"service account": {
+			kind:    MemberKind{},
+			subject: "system:serviceaccount:payments:api",
+		},
+		"template with labels": {
+			kind:    MemberKind{APIVersion: "apps/v1", Kind: "Deployment", SubjectTemplate: "teams:{{.Labels.team}}:{{.Name}}"},
+			subject: "teams:billing:api",
+		},
+		"missing label": {
+			kind:  MemberKind{APIVersion: "apps/v1", Kind: "Deployment", SubjectTemplate: "teams:{{.Labels.owner}}"},
+			fails: true,
+		},
+		"empty subject": {
+			kind:  MemberKind{APIVersion: "apps/v1", Kind: "Deployment", SubjectTemplate: " "},
+			fails: true,
+		},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			tmpl, err := tc.kind.Template()
+			require.NoError(t, err)
+
+			//when
+			subject, err := RenderSubject(tmpl, obj)
+
+			//then
+			if tc.fails {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.subject, subject)
+		})
+	}
+}
+
+func TestRoleMembers(t *testing.T) {
+
+	//given
+	r := &Role{
+		Spec:   RoleSpec{Members: []string{"users:bob", "users:alice"}},
+		Status: RoleStatus{ResolvedMembers: []string{"system:serviceaccount:default:api", "users:bob"}},
+	}
+
+	//when
+	members := r.Members()
+
+	//then
+	assert.Equal(t, []string{"system:serviceaccount:default:api", "users:alice", "users:bob"}, members)
+}
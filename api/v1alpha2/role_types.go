@@ -0,0 +1,156 @@
+package v1alpha2
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ory/keto-maester/keto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// GenerateId returns the default ID of a policy or role in ORY Keto
+func GenerateId(named metav1.Object) string {
+	return fmt.Sprintf("%s:%s", named.GetNamespace(), named.GetName())
+}
+
+// ParseGeneratedId returns the namespace and name of an ID shaped like the ones GenerateId returns
+func ParseGeneratedId(id string) (namespace, name string, ok bool) {
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 || len(validation.IsDNS1123Label(parts[0])) > 0 || len(validation.IsDNS1123Subdomain(parts[1])) > 0 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// KetoKey identifies a policy or role in ORY Keto by its flavour and ID
+func KetoKey(flavour Flavour, id string) string {
+	if flavour == "" {
+		flavour = DefaultFlavour
+	}
+	return fmt.Sprintf("%s/%s", flavour, id)
+}
+
+// RoleStatus defines the observed state of Role
+type RoleStatus struct {
+	// ObservedGeneration is the most recent generation the conditions were set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// KetoID is the ID the role was last applied with in ORY Keto
+	KetoID string `json:"ketoId,omitempty"`
+
+	// Flavour is the pattern matching flavour the role was last applied with in ORY Keto
+	Flavour Flavour `json:"flavour,omitempty"`
+
+	// ResolvedMembers are the members the member references and the member selector resolved to during the last reconciliation
+	ResolvedMembers []string `json:"resolvedMembers,omitempty"`
+
+	// ManagedMembers are the members the controller added to the role in Keto during the last reconciliation
+	ManagedMembers []string `json:"managedMembers,omitempty"`
+
+	// Conditions are the Ready, Synced, Degraded and Paused conditions of the role
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// RoleSpec defines the desired state of Ory Keto Role
+type RoleSpec struct {
+	// KetoID is the ID of the role in ORY Keto, defaults to namespace:name
+	// +optional
+	KetoID string `json:"ketoId,omitempty"`
+
+	// Flavour is the pattern matching flavour of the policies the role is used in, defaults to exact
+	// (more info https://www.ory.sh/keto/docs/engines/acp-ory#pattern-matching-strategies)
+	// +optional
+	Flavour Flavour `json:"flavour,omitempty"`
+
+	// Members of role
+	Members []string `json:"members,omitempty"`
+
+	// MemberRefs add the subjects of Kubernetes objects as members, e.g. service accounts or other roles
+	// +optional
+	MemberRefs []MemberRef `json:"memberRefs,omitempty"`
+
+	// MemberSelector adds the subjects of the objects in the namespace of the role matching it as members
+	// +optional
+	MemberSelector *MemberSelector `json:"memberSelector,omitempty"`
+
+	// Defines how members are applied to the role in Keto. With "replace" the members of the role are exactly
+	// the ones listed above, with "merge" only members listed here are added and removed, leaving members
+	// added by other systems in place
+	// +optional
+	MembershipMode MembershipMode `json:"membershipMode,omitempty"`
+
+	// DeletionPolicy tells whether the role is deleted from ORY Keto or kept there when the object is
+	// deleted, defaults to the deletion policy of the controller
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=replace;merge
+type MembershipMode string
+
+const (
+	MembershipReplace MembershipMode = "replace"
+	MembershipMerge   MembershipMode = "merge"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Role is the Schema for the keto role API
+type Role struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RoleSpec   `json:"spec,omitempty"`
+	Status RoleStatus `json:"status,omitempty"`
+}
+
+// Hub marks v1alpha2 as the version other versions of Role are converted through
+func (*Role) Hub() {}
+
+func (r *Role) SetObservedGeneration(generation int64) {
+	r.Status.ObservedGeneration = generation
+}
+
+func (r *Role) SetCondition(condition Condition) {
+	SetCondition(&r.Status.Conditions, condition)
+}
+
+// KetoID returns the ID of the role in ORY Keto
+func (r *Role) KetoID() string {
+	if r.Spec.KetoID != "" {
+		return r.Spec.KetoID
+	}
+	return GenerateId(r)
+}
+
+// KetoKey identifies the role in ORY Keto, roles with the same key overwrite each other
+func (r *Role) KetoKey() string {
+	return KetoKey(r.Spec.Flavour, r.KetoID())
+}
+
+// +kubebuilder:object:root=true
+
+//RoleList contains a list of Role
+type RoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Role `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Role{}, &RoleList{})
+}
+
+func (r *Role) ToRoleJSON() *keto.Role {
+	return &keto.Role{
+		Id:      r.KetoID(),
+		Members: r.Members(),
+	}
+}
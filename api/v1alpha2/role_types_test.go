@@ -0,0 +1,54 @@
+package v1alpha2
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseGeneratedId(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		id        string
+		namespace string
+		name      string
+		ok        bool
+	}{
+		"generated id":        {id: "payments:read", namespace: "payments", name: "read", ok: true},
+		"name with dots":      {id: "payments:read.v1", namespace: "payments", name: "read.v1", ok: true},
+		"name with colon":     {id: "payments:read:all"},
+		"missing name":        {id: "payments:"},
+		"missing namespace":   {id: ":read"},
+		"no separator":        {id: "payments"},
+		"uppercase namespace": {id: "Payments:read"},
+		"namespace with dots": {id: "pay.ments:read"},
+		"pattern":             {id: "payments:<.*>"},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//when
+			namespace, name, ok := ParseGeneratedId(tc.id)
+
+			//then
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.namespace, namespace)
+			assert.Equal(t, tc.name, name)
+		})
+	}
+}
+
+func TestParseGeneratedIdRoundTrip(t *testing.T) {
+
+	//given
+	role := &Role{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "admins"}}
+
+	//when
+	namespace, name, ok := ParseGeneratedId(GenerateId(role))
+
+	//then
+	assert.True(t, ok)
+	assert.Equal(t, "payments", namespace)
+	assert.Equal(t, "admins", name)
+}
@@ -0,0 +1,131 @@
+package v1alpha2
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ory/keto-maester/keto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Validate returns the problems ORY Keto would reject the policy for
+func (p *Policy) Validate() field.ErrorList {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+	flavour := keto.Flavour(p.Spec.Flavour)
+
+	errs = append(errs, validateKetoID(spec.Child("ketoId"), p.Spec.KetoID)...)
+	errs = append(errs, validatePatterns(spec.Child("subjects"), flavour, p.Spec.Subjects, false)...)
+	for i, ref := range p.Spec.SubjectRefs {
+		if strings.TrimSpace(ref.Name) == "" {
+			errs = append(errs, field.Required(spec.Child("subjectRefs").Index(i).Child("name"), "the name of the referenced role is required"))
+		}
+	}
+	errs = append(errs, validatePatterns(spec.Child("actions"), flavour, p.Spec.Actions, true)...)
+	errs = append(errs, validatePatterns(spec.Child("resources"), flavour, p.Spec.Resources, true)...)
+
+	names := make([]string, 0, len(p.Spec.Conditions))
+	for name := range p.Spec.Conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		condition := p.Spec.Conditions[name]
+		path := spec.Child("conditions").Key(name)
+		converted, err := condition.ToKeto()
+		switch {
+		case err != nil:
+			errs = append(errs, field.Invalid(path, name, err.Error()))
+		case condition.Custom != nil:
+			if strings.TrimSpace(condition.Custom.Type) == "" {
+				errs = append(errs, field.Required(path.Child("custom", "type"), "the type of custom conditions is required"))
+			}
+		default:
+			if err := keto.ValidateCondition(converted); err != nil {
+				errs = append(errs, field.Invalid(path, name, err.Error()))
+			}
+		}
+	}
+
+	return errs
+}
+
+// Validate returns the problems ORY Keto would reject the role for, or which would make the role ambiguous
+func (r *Role) Validate() field.ErrorList {
+	errs := validateKetoID(field.NewPath("spec", "ketoId"), r.Spec.KetoID)
+	path := field.NewPath("spec", "members")
+
+	seen := map[string]bool{}
+	for i, member := range r.Spec.Members {
+		switch {
+		case strings.TrimSpace(member) == "":
+			errs = append(errs, field.Required(path.Index(i), "members must not be empty"))
+		case seen[member]:
+			errs = append(errs, field.Duplicate(path.Index(i), member))
+		}
+		seen[member] = true
+	}
+
+	for i, ref := range r.Spec.MemberRefs {
+		path := field.NewPath("spec", "memberRefs").Index(i)
+		if strings.TrimSpace(ref.Name) == "" {
+			errs = append(errs, field.Required(path.Child("name"), "the name of the referenced object is required"))
+		}
+		if ref.IsRole() && ref.RoleMembership != RoleMembershipNested && ref.Name == r.Name && (ref.Namespace == "" || ref.Namespace == r.Namespace) {
+			errs = append(errs, field.Invalid(path.Child("name"), ref.Name, "a role can't be a member of itself"))
+		}
+		errs = append(errs, validateMemberKind(path, ref.MemberKind)...)
+	}
+
+	if selector := r.Spec.MemberSelector; selector != nil {
+		path := field.NewPath("spec", "memberSelector")
+		if _, err := metav1.LabelSelectorAsSelector(&selector.LabelSelector); err != nil {
+			errs = append(errs, field.Invalid(path, selector.LabelSelector, err.Error()))
+		}
+		errs = append(errs, validateMemberKind(path, selector.MemberKind)...)
+	}
+
+	return errs
+}
+
+// validateMemberKind checks that members can be resolved from objects of kind
+func validateMemberKind(path *field.Path, kind MemberKind) field.ErrorList {
+	if _, err := kind.GroupVersionKind(); err != nil {
+		return field.ErrorList{field.Invalid(path.Child("apiVersion"), kind.APIVersion, err.Error())}
+	}
+	if kind.IsRole() {
+		return nil
+	}
+	if _, err := kind.Template(); err != nil {
+		return field.ErrorList{field.Invalid(path.Child("subjectTemplate"), kind.SubjectTemplate, err.Error())}
+	}
+	return nil
+}
+
+// validateKetoID rejects IDs which can't be used in the paths of the API of ORY Keto
+func validateKetoID(path *field.Path, id string) field.ErrorList {
+	if id != "" && (strings.TrimSpace(id) != id || strings.Contains(id, "/")) {
+		return field.ErrorList{field.Invalid(path, id, "must not contain slashes or surrounding whitespace")}
+	}
+	return nil
+}
+
+func validatePatterns(path *field.Path, flavour keto.Flavour, patterns []string, required bool) field.ErrorList {
+	var errs field.ErrorList
+	if required && len(patterns) == 0 {
+		errs = append(errs, field.Required(path, "at least one entry is required"))
+	}
+
+	for i, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			errs = append(errs, field.Required(path.Index(i), "entries must not be empty"))
+			continue
+		}
+		if err := keto.ValidatePattern(flavour, pattern); err != nil {
+			errs = append(errs, field.Invalid(path.Index(i), pattern, err.Error()))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,130 @@
+package v1alpha2
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPolicyValidate(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		spec   PolicySpec
+		errors int
+	}{
+		"valid regex policy": {
+			spec: PolicySpec{Flavour: "regex", Subjects: []string{"users:<.*>"}, Actions: []string{"read"}, Resources: []string{"blog:<[0-9]+>"}},
+		},
+		"invalid regex subject": {
+			spec:   PolicySpec{Flavour: "regex", Subjects: []string{"users:<[>"}, Actions: []string{"read"}, Resources: []string{"blog"}},
+			errors: 1,
+		},
+		"missing actions and resources": {
+			spec:   PolicySpec{Flavour: "exact", Subjects: []string{"users:alice"}},
+			errors: 2,
+		},
+		"empty action": {
+			spec:   PolicySpec{Flavour: "exact", Actions: []string{" "}, Resources: []string{"blog"}},
+			errors: 1,
+		},
+		"subject ref without name": {
+			spec:   PolicySpec{Flavour: "exact", SubjectRefs: []RoleRef{{Namespace: "payments"}}, Actions: []string{"read"}, Resources: []string{"blog"}},
+			errors: 1,
+		},
+		"keto id with a slash": {
+			spec:   PolicySpec{KetoID: "payments/read", Flavour: "exact", Actions: []string{"read"}, Resources: []string{"blog"}},
+			errors: 1,
+		},
+		"invalid typed condition": {
+			spec:   PolicySpec{Flavour: "exact", Actions: []string{"read"}, Resources: []string{"blog"}, Conditions: map[string]PolicyCondition{"ip": {CIDR: &CIDRCondition{CIDR: "10.0.0.0"}}}},
+			errors: 1,
+		},
+		"custom condition without type": {
+			spec:   PolicySpec{Flavour: "exact", Actions: []string{"read"}, Resources: []string{"blog"}, Conditions: map[string]PolicyCondition{"owner": {Custom: &CustomCondition{}}}},
+			errors: 1,
+		},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			p := &Policy{Spec: tc.spec}
+
+			//when
+			errs := p.Validate()
+
+			//then
+			assert.Len(t, errs, tc.errors, "unexpected errors %v", errs)
+		})
+	}
+}
+
+func TestRoleValidate(t *testing.T) {
+
+	//given
+	r := &Role{Spec: RoleSpec{KetoID: " admins", Members: []string{"alice", "", "bob", "alice"}}}
+
+	//when
+	errs := r.Validate()
+
+	//then
+	if assert.Len(t, errs, 3) {
+		assert.Equal(t, "spec.ketoId", errs[0].Field)
+		assert.Equal(t, "spec.members[1]", errs[1].Field)
+		assert.Equal(t, "spec.members[3]", errs[2].Field)
+	}
+}
+
+func TestRoleValidateMemberRefs(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		spec   RoleSpec
+		errors int
+	}{
+		"service account": {
+			spec: RoleSpec{MemberRefs: []MemberRef{{Name: "deployer"}}},
+		},
+		"role": {
+			spec: RoleSpec{MemberRefs: []MemberRef{{MemberKind: MemberKind{Kind: "Role"}, Name: "admins"}}},
+		},
+		"role referencing itself": {
+			spec:   RoleSpec{MemberRefs: []MemberRef{{MemberKind: MemberKind{Kind: "Role"}, Name: "self"}}},
+			errors: 1,
+		},
+		"kind without template": {
+			spec:   RoleSpec{MemberRefs: []MemberRef{{MemberKind: MemberKind{APIVersion: "apps/v1", Kind: "Deployment"}, Name: "api"}}},
+			errors: 1,
+		},
+		"kind without api version": {
+			spec:   RoleSpec{MemberRefs: []MemberRef{{MemberKind: MemberKind{Kind: "Deployment", SubjectTemplate: "apps:{{.Name}}"}, Name: "api"}}},
+			errors: 1,
+		},
+		"missing name": {
+			spec:   RoleSpec{MemberRefs: []MemberRef{{}}},
+			errors: 1,
+		},
+		"invalid selector": {
+			spec:   RoleSpec{MemberSelector: &MemberSelector{LabelSelector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Near"}}}}},
+			errors: 1,
+		},
+		"selector with template": {
+			spec: RoleSpec{MemberSelector: &MemberSelector{
+				MemberKind:    MemberKind{APIVersion: "apps/v1", Kind: "Deployment", SubjectTemplate: "apps:{{.Namespace}}:{{.Name}}"},
+				LabelSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+			}},
+		},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			r := &Role{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "self"}, Spec: tc.spec}
+
+			//when
+			errs := r.Validate()
+
+			//then
+			assert.Len(t, errs, tc.errors, "unexpected errors %v", errs)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ketoClaimant is a policy or role claiming an ID in ORY Keto
+type ketoClaimant interface {
+	metav1.Object
+	KetoKey() string
+}
+
+// claimOwner returns the claimant which owns the ID in ORY Keto. The one created first wins, so that
+// a new object can never take over the ID of an existing one.
+func claimOwner(claimants []ketoClaimant) ketoClaimant {
+	var owner ketoClaimant
+	for _, c := range claimants {
+		if owner == nil || claimsBefore(c, owner) {
+			owner = c
+		}
+	}
+	return owner
+}
+
+func claimsBefore(a, b ketoClaimant) bool {
+	ta, tb := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	if !ta.Equal(&tb) {
+		return ta.Before(&tb)
+	}
+	if a.GetNamespace() != b.GetNamespace() {
+		return a.GetNamespace() < b.GetNamespace()
+	}
+	return a.GetName() < b.GetName()
+}
+
+// otherClaimants returns the claimants of list which aren't obj
+func otherClaimants(obj ketoClaimant, list []ketoClaimant) []ketoClaimant {
+	var others []ketoClaimant
+	for _, c := range list {
+		if c.GetNamespace() != obj.GetNamespace() || c.GetName() != obj.GetName() {
+			others = append(others, c)
+		}
+	}
+	return others
+}
+
+// ketoIDConflict returns the object owning the ID obj claims in ORY Keto if it isn't obj, claimants
+// may include a copy of obj
+func ketoIDConflict(obj ketoClaimant, claimants []ketoClaimant) ketoClaimant {
+	if owner := claimOwner(append(otherClaimants(obj, claimants), obj)); owner != obj {
+		return owner
+	}
+	return nil
+}
+
+// claimantLister lists the objects of one kind claiming key in ORY Keto
+type claimantLister func(ctx context.Context, c client.Reader, key string) ([]ketoClaimant, error)
+
+// claimedByOthers tells whether any object but obj claims key in ORY Keto, in which case whatever lives
+// there is left to that object
+func claimedByOthers(ctx context.Context, c client.Reader, list claimantLister, obj ketoClaimant, key string) (bool, error) {
+	claimants, err := list(ctx, c, key)
+	if err != nil {
+		return false, err
+	}
+	return len(otherClaimants(obj, claimants)) > 0, nil
+}
+
+// ketoLocation is the flavour and ID a policy or role is stored under in ORY Keto
+type ketoLocation struct {
+	flavour keto.Flavour
+	id      string
+}
+
+func (l ketoLocation) key() string {
+	return ketov1alpha2.KetoKey(ketov1alpha2.Flavour(l.flavour), l.id)
+}
+
+// updateKetoIDConflictStatus records that obj isn't applied to ORY Keto because owner claims the same ID
+func updateKetoIDConflictStatus(ctx context.Context, r ReconcilerInterface, obj WithStatus, owner ketoClaimant) error {
+	message := fmt.Sprintf("the ID in ORY Keto is already claimed by %s %s/%s", r.GetResource(), owner.GetNamespace(), owner.GetName())
+	r.GetLog().Info(fmt.Sprintf("not applying %s %s/%s", r.GetResource(), obj.GetName(), obj.GetNamespace()), "reason", message)
+	setSyncConditions(obj, ketov1alpha2.ReasonKetoIDConflict, message)
+
+	return updateStatus(ctx, r, obj)
+}
+
+// enqueueKetoClaimants requeues the objects claiming the same ID in ORY Keto as the changed object, so that
+// the next one in line takes the ID over once its owner is gone or claims another ID
+func enqueueKetoClaimants(c client.Reader, list claimantLister) handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+		changed, ok := o.Object.(ketoClaimant)
+		if !ok {
+			return nil
+		}
+
+		claimants, err := list(context.Background(), c, changed.KetoKey())
+		if err != nil {
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, claimant := range otherClaimants(changed, claimants) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: claimant.GetNamespace(), Name: claimant.GetName()}})
+		}
+		return requests
+	})}
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultCredentialsTTL = time.Minute
+
+// SecretCredentials provides the credentials for ORY Keto from the keys of a Kubernetes Secret.
+// The Secret is read again once the cached copy is older than TTL, so rotated credentials are
+// picked up while the manager is running. Reading it only needs get on that one Secret, see
+// config/rbac/credentials_role.yaml, so Reader should read from the API server rather than a cache.
+type SecretCredentials struct {
+	Reader client.Reader
+	Secret types.NamespacedName
+	TTL    time.Duration
+
+	mu      sync.Mutex
+	fetched time.Time
+	cached  map[string]string
+}
+
+func (s *SecretCredentials) Credentials(ctx context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ttl := s.TTL
+	if ttl == 0 {
+		ttl = defaultCredentialsTTL
+	}
+	if s.cached != nil && time.Since(s.fetched) < ttl {
+		return s.cached, nil
+	}
+
+	var secret apiv1.Secret
+	if err := s.Reader.Get(ctx, s.Secret, &secret); err != nil {
+		return nil, err
+	}
+
+	credentials := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		credentials[key] = strings.TrimSpace(string(value))
+	}
+
+	s.cached, s.fetched = credentials, time.Now()
+	return credentials, nil
+}
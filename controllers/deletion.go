@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// retains tells whether objects with deletionPolicy keep their policy or role in ORY Keto once deleted
+func (r *Reconciler) retains(deletionPolicy ketov1alpha2.DeletionPolicy) bool {
+	if deletionPolicy == "" {
+		deletionPolicy = r.DeletionPolicy
+	}
+	return deletionPolicy == ketov1alpha2.DeletionPolicyRetain
+}
+
+// retainLocations records that the resource at locations is kept in ORY Keto, if retained locations are
+// recorded, so that the garbage collector leaves it there
+func (r *Reconciler) retainLocations(ctx context.Context, resource string, locations []ketoLocation) error {
+	if r.Retained == nil {
+		return nil
+	}
+	return r.Retained.Retain(ctx, resource, locations...)
+}
+
+// releaseLocations forgets that the resource at locations was kept in ORY Keto, once it is deleted from there
+func (r *Reconciler) releaseLocations(ctx context.Context, resource string, locations []ketoLocation) error {
+	if r.Retained == nil {
+		return nil
+	}
+	return r.Retained.Release(ctx, resource, locations...)
+}
+
+// recordRetained records that obj is deleted without removing what it applied at location from ORY Keto
+func recordRetained(r ReconcilerInterface, obj WithStatus, location ketoLocation) {
+	message := fmt.Sprintf("kept %s %s in ORY Keto as the deletion policy is %s", r.GetResource(), location.key(), ketov1alpha2.DeletionPolicyRetain)
+	r.GetLog().Info(fmt.Sprintf("retaining %s %s/%s", r.GetResource(), obj.GetName(), obj.GetNamespace()), "reason", message)
+	recordEvent(r, obj, corev1.EventTypeNormal, ReasonRetained, message)
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var retainedConfigMap = types.NamespacedName{Namespace: "keto-maester-system", Name: "keto-maester-retained"}
+
+func newTestRetainedLocations(c client.Client) *RetainedLocations {
+	return &RetainedLocations{Reader: c, Writer: c, ConfigMap: retainedConfigMap}
+}
+
+func deletedPolicy(deletionPolicy ketov1alpha2.DeletionPolicy) *ketov1alpha2.Policy {
+	now := metav1.Now()
+	p := testPolicy()
+	p.Finalizers = []string{FinalizerName}
+	p.DeletionTimestamp = &now
+	p.Spec.DeletionPolicy = deletionPolicy
+	return p
+}
+
+func TestDeletionPolicy(t *testing.T) {
+
+	exact := ketoLocation{keto.Exact, "default:readers"}
+
+	t.Run("retain skips the delete and records the policy", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id}
+		r, recorder := newTestReconciler(ketoClient, deletedPolicy(ketov1alpha2.DeletionPolicyRetain))
+		r.Retained = newTestRetainedLocations(r.Client)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Contains(t, ketoClient.policies, exact)
+		assert.NotContains(t, reconciled.Finalizers, FinalizerName)
+		retained, err := r.Retained.List(context.Background(), "policy")
+		require.NoError(t, err)
+		assert.Equal(t, map[ketoLocation]bool{exact: true}, retained)
+		assert.Equal(t, []string{"Normal Retained kept policy exact/default:readers in ORY Keto as the deletion policy is Retain"}, events(recorder))
+	})
+
+	t.Run("retain is the default of the controller", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id}
+		r, _ := newTestReconciler(ketoClient, deletedPolicy(""))
+		r.DeletionPolicy = ketov1alpha2.DeletionPolicyRetain
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Contains(t, ketoClient.policies, exact)
+	})
+
+	t.Run("delete removes the policy and forgets it was retained", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id}
+		retained := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: retainedConfigMap.Namespace, Name: retainedConfigMap.Name},
+			Data:       map[string]string{"policy": "exact/default:readers\nregex/other"},
+		}
+		r, recorder := newTestReconciler(ketoClient, deletedPolicy(ketov1alpha2.DeletionPolicyDelete), retained)
+		r.Retained = newTestRetainedLocations(r.Client)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.NotContains(t, ketoClient.policies, exact)
+		locations, err := r.Retained.List(context.Background(), "policy")
+		require.NoError(t, err)
+		assert.Equal(t, map[ketoLocation]bool{{keto.Regex, "other"}: true}, locations)
+		assert.Equal(t, []string{"Normal Deleted deleted policy exact/default:readers from ORY Keto"}, events(recorder))
+	})
+
+	t.Run("retain keeps members of roles", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"alice"}}
+		now := metav1.Now()
+		role := testRole("alice")
+		role.Finalizers = []string{FinalizerName}
+		role.DeletionTimestamp = &now
+		role.Spec.DeletionPolicy = ketov1alpha2.DeletionPolicyRetain
+		r, _ := newTestRoleReconciler(ketoClient, role)
+		r.Retained = newTestRetainedLocations(r.Client)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.Equal(t, []string{"alice"}, ketoClient.roles[exact].Members)
+		retained, err := r.Retained.List(context.Background(), "role")
+		require.NoError(t, err)
+		assert.Equal(t, map[ketoLocation]bool{exact: true}, retained)
+	})
+}
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// recordDrift records that obj was found to differ in ORY Keto from its desired state, although it was
+// applied already and didn't change since
+func recordDrift(r ReconcilerInterface, obj WithStatus, exists bool) {
+	message := fmt.Sprintf("the %s differs in ORY Keto from its desired state, re-applying it", r.GetResource())
+	if !exists {
+		message = fmt.Sprintf("the %s is missing in ORY Keto, re-applying it", r.GetResource())
+	}
+
+	r.GetLog().Info(fmt.Sprintf("drift detected for %s %s/%s", r.GetResource(), obj.GetName(), obj.GetNamespace()), "reason", message)
+	driftDetected.WithLabelValues(r.GetResource()).Inc()
+	recordEvent(r, obj, corev1.EventTypeWarning, ReasonDriftDetected, message)
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"testing"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// appliedPolicy returns the test policy as it is after it was applied
+func appliedPolicy() *ketov1alpha2.Policy {
+	p := testPolicy()
+	p.Finalizers = []string{FinalizerName}
+	p.Status.ObservedGeneration = p.Generation
+	p.Status.KetoID = "default:readers"
+	p.Status.Flavour = ketov1alpha2.Flavour(keto.Exact)
+	setSyncConditions(p, ketov1alpha2.ReasonSynced, "")
+	return p
+}
+
+// metricValue returns the value of a counter or a gauge
+func metricValue(t *testing.T, metric prometheus.Metric) float64 {
+	var m dto.Metric
+	require.NoError(t, metric.Write(&m))
+	if m.Counter != nil {
+		return m.Counter.GetValue()
+	}
+	return m.Gauge.GetValue()
+}
+
+func TestDrift(t *testing.T) {
+
+	exact := ketoLocation{keto.Exact, "default:readers"}
+
+	t.Run("leaves an unchanged policy alone", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id, Subjects: []string{"alice"}, Actions: []string{"read"}, Resources: []string{"books"}, Effect: "allow"}
+		r, recorder := newTestReconciler(ketoClient, appliedPolicy())
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Equal(t, 0, ketoClient.writes)
+		assert.Empty(t, events(recorder))
+	})
+
+	t.Run("re-applies a policy changed in ORY Keto", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id, Subjects: []string{"mallory"}, Actions: []string{"read"}, Resources: []string{"books"}, Effect: "allow"}
+		r, recorder := newTestReconciler(ketoClient, appliedPolicy())
+		before := metricValue(t, driftDetected.WithLabelValues("policy"))
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Equal(t, []string{"alice"}, ketoClient.policies[exact].Subjects)
+		assert.Equal(t, before+1, metricValue(t, driftDetected.WithLabelValues("policy")))
+		assert.Equal(t, []string{
+			"Warning DriftDetected the policy differs in ORY Keto from its desired state, re-applying it",
+			"Normal DriftCorrected re-applied policy exact/default:readers in ORY Keto",
+		}, events(recorder))
+	})
+
+	t.Run("re-applies a policy deleted from ORY Keto", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		r, recorder := newTestReconciler(ketoClient, appliedPolicy())
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		require.Contains(t, ketoClient.policies, exact)
+		assert.Equal(t, []string{
+			"Warning DriftDetected the policy is missing in ORY Keto, re-applying it",
+			"Normal DriftCorrected re-applied policy exact/default:readers in ORY Keto",
+		}, events(recorder))
+	})
+
+	t.Run("re-adds members removed from a role in ORY Keto", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"alice"}}
+		role := testRole("alice", "bob")
+		role.Finalizers = []string{FinalizerName}
+		role.Status.ObservedGeneration = role.Generation
+		role.Status.KetoID = exact.id
+		role.Status.Flavour = ketov1alpha2.Flavour(keto.Exact)
+		setSyncConditions(role, ketov1alpha2.ReasonSynced, "")
+		r, recorder := newTestRoleReconciler(ketoClient, role)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.ElementsMatch(t, []string{"alice", "bob"}, ketoClient.roles[exact].Members)
+		assert.Equal(t, []string{
+			"Warning DriftDetected the role differs in ORY Keto from its desired state, re-applying it",
+			"Normal DriftCorrected re-applied role exact/default:readers in ORY Keto",
+		}, events(recorder))
+	})
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events recorded on policies and roles, errors are recorded with the reason of the
+// Synced condition
+const (
+	ReasonCreated        = "Created"
+	ReasonUpdated        = "Updated"
+	ReasonUnchanged      = "Unchanged"
+	ReasonDriftDetected  = "DriftDetected"
+	ReasonDriftCorrected = "DriftCorrected"
+	ReasonDeleted        = "Deleted"
+	ReasonRetained       = "Retained"
+)
+
+const defaultEventInterval = 5 * time.Minute
+
+// recordEvent records an event on obj, if the reconciler has a recorder
+func recordEvent(r ReconcilerInterface, obj WithStatus, eventType, reason, message string) {
+	if recorder := r.GetRecorder(); recorder != nil {
+		recorder.Event(obj, eventType, reason, message)
+	}
+}
+
+// recordApplied records that obj was written to location in ORY Keto, because it didn't exist there yet,
+// because it changed or because it was found to differ from its desired state
+func recordApplied(r ReconcilerInterface, obj WithStatus, location ketoLocation, existed, drifted bool) {
+	switch {
+	case drifted:
+		recordEvent(r, obj, corev1.EventTypeNormal, ReasonDriftCorrected, fmt.Sprintf("re-applied %s %s in ORY Keto", r.GetResource(), location.key()))
+	case !existed:
+		recordEvent(r, obj, corev1.EventTypeNormal, ReasonCreated, fmt.Sprintf("created %s %s in ORY Keto", r.GetResource(), location.key()))
+	default:
+		recordEvent(r, obj, corev1.EventTypeNormal, ReasonUpdated, fmt.Sprintf("updated %s %s in ORY Keto", r.GetResource(), location.key()))
+	}
+}
+
+// recordUnchanged records that obj matches its desired state at location in ORY Keto although nothing was
+// written there. Reconciliations which neither write to ORY Keto nor change the outcome record no event at all.
+func recordUnchanged(r ReconcilerInterface, obj WithStatus, location ketoLocation) {
+	recordEvent(r, obj, corev1.EventTypeNormal, ReasonUnchanged, fmt.Sprintf("%s %s in ORY Keto is up to date", r.GetResource(), location.key()))
+}
+
+// RateLimitedRecorder drops events which repeat an event recorded on the same object less than Interval ago, so that e.g. an unavailable ORY Keto doesn't flood the API server with
+// an event per retry
+type RateLimitedRecorder struct {
+	Recorder record.EventRecorder
+	// Interval between two identical events on the same object, defaults to 5 minutes
+	Interval time.Duration
+
+	mu    sync.Mutex
+	last  map[eventKey]time.Time
+	swept time.Time
+}
+
+// eventKey tells events apart, kind is the Go type since typed objects read from the API have no kind set
+type eventKey struct {
+	kind, namespace, name string
+	eventType, reason     string
+	message               string
+}
+
+var _ record.EventRecorder = &RateLimitedRecorder{}
+
+func (r *RateLimitedRecorder) Event(object runtime.Object, eventType, reason, message string) {
+	if r.allow(object, eventType, reason, message) {
+		r.Recorder.Event(object, eventType, reason, message)
+	}
+}
+
+func (r *RateLimitedRecorder) Eventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.allow(object, eventType, reason, fmt.Sprintf(messageFmt, args...)) {
+		r.Recorder.Eventf(object, eventType, reason, messageFmt, args...)
+	}
+}
+
+func (r *RateLimitedRecorder) PastEventf(object runtime.Object, timestamp metav1.Time, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.allow(object, eventType, reason, fmt.Sprintf(messageFmt, args...)) {
+		r.Recorder.PastEventf(object, timestamp, eventType, reason, messageFmt, args...)
+	}
+}
+
+func (r *RateLimitedRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.allow(object, eventType, reason, fmt.Sprintf(messageFmt, args...)) {
+		r.Recorder.AnnotatedEventf(object, annotations, eventType, reason, messageFmt, args...)
+	}
+}
+
+// allow tells whether an event may be recorded now, and if so remembers it
+func (r *RateLimitedRecorder) allow(object runtime.Object, eventType, reason, message string) bool {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		// nothing to tell the objects apart by, the recorder reports the object itself
+		return true
+	}
+	key := eventKey{
+		kind:      fmt.Sprintf("%T", object),
+		namespace: accessor.GetNamespace(),
+		name:      accessor.GetName(),
+		eventType: eventType,
+		reason:    reason,
+		message:   message,
+	}
+
+	interval := r.Interval
+	if interval == 0 {
+		interval = defaultEventInterval
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.last == nil {
+		r.last = map[eventKey]time.Time{}
+	}
+	if now.Sub(r.swept) > interval {
+		// forget the events which don't hold anything back anymore, so that deleted objects aren't kept
+		for k, t := range r.last {
+			if now.Sub(t) >= interval {
+				delete(r.last, k)
+			}
+		}
+		r.swept = now
+	}
+
+	if t, ok := r.last[key]; ok && now.Sub(t) < interval {
+		return false
+	}
+	r.last[key] = now
+	return true
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestRateLimitedRecorder(t *testing.T) {
+
+	t.Run("drops events repeated within the interval", func(t *testing.T) {
+
+		//given
+		recorder := record.NewFakeRecorder(100)
+		limited := &RateLimitedRecorder{Recorder: recorder, Interval: time.Hour}
+		p := testPolicy()
+
+		//when
+		limited.Event(p, corev1.EventTypeWarning, ketov1alpha2.ReasonKetoUnavailable, "ORY Keto is unavailable")
+		limited.Event(p, corev1.EventTypeWarning, ketov1alpha2.ReasonKetoUnavailable, "ORY Keto is unavailable")
+		limited.Eventf(p, corev1.EventTypeWarning, ketov1alpha2.ReasonKetoUnavailable, "ORY Keto is %s", "unavailable")
+
+		//then
+		assert.Equal(t, []string{"Warning KetoUnavailable ORY Keto is unavailable"}, events(recorder))
+	})
+
+	t.Run("tells events of other objects, reasons and messages apart", func(t *testing.T) {
+
+		//given
+		recorder := record.NewFakeRecorder(100)
+		limited := &RateLimitedRecorder{Recorder: recorder, Interval: time.Hour}
+		p, other, role := testPolicy(), testPolicy(), testRole()
+		other.Name = "other"
+
+		//when
+		limited.Event(p, corev1.EventTypeNormal, ReasonCreated, "created")
+		limited.Event(other, corev1.EventTypeNormal, ReasonCreated, "created")
+		limited.Event(role, corev1.EventTypeNormal, ReasonCreated, "created")
+		limited.Event(p, corev1.EventTypeNormal, ReasonUpdated, "created")
+		limited.Event(p, corev1.EventTypeNormal, ReasonCreated, "updated")
+
+		//then
+		assert.Len(t, events(recorder), 5)
+	})
+
+	t.Run("records repeated events again after the interval", func(t *testing.T) {
+
+		//given
+		recorder := record.NewFakeRecorder(100)
+		limited := &RateLimitedRecorder{Recorder: recorder, Interval: 10 * time.Millisecond}
+		p := testPolicy()
+		limited.Event(p, corev1.EventTypeNormal, ReasonUnchanged, "up to date")
+
+		//when
+		time.Sleep(20 * time.Millisecond)
+		limited.Event(p, corev1.EventTypeNormal, ReasonUnchanged, "up to date")
+
+		//then
+		assert.Len(t, events(recorder), 2)
+	})
+}
+
+func TestOutcomeEvents(t *testing.T) {
+
+	exact := ketoLocation{keto.Exact, "default:readers"}
+
+	t.Run("records updates of changed policies", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id}
+		p := appliedPolicy()
+		p.Generation = 2
+		r, recorder := newTestReconciler(ketoClient, p)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Equal(t, []string{"Normal Updated updated policy exact/default:readers in ORY Keto"}, events(recorder))
+	})
+
+	t.Run("records failures once per interval", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.err = fmt.Errorf("connection refused")
+		r, recorder := newTestReconciler(ketoClient, testPolicy())
+		r.Recorder = &RateLimitedRecorder{Recorder: recorder, Interval: time.Hour}
+		reconciler := &KetoPolicyReconciler{Reconciler: r}
+
+		//when
+		for i := 0; i < 3; i++ {
+			_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: policyName})
+			assert.Error(t, err)
+		}
+
+		//then
+		assert.Equal(t, []string{"Warning ReconcileError connection refused"}, events(recorder))
+	})
+}
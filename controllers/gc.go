@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GCMode is what the garbage collector does with orphaned policies and roles
+type GCMode string
+
+const (
+	GCOff    GCMode = "off"
+	GCReport GCMode = "report"
+	GCDelete GCMode = "delete"
+)
+
+const defaultGCInterval = time.Hour
+
+// Reasons of the events recorded by the garbage collector
+const (
+	ReasonOrphanFound   = "OrphanFound"
+	ReasonOrphanDeleted = "OrphanDeleted"
+)
+
+// ketoFlavours are the stores of ORY Keto policies and roles may live in
+var ketoFlavours = []keto.Flavour{keto.Exact, keto.Regex, keto.Glob}
+
+// GarbageCollector finds policies and roles in ORY Keto which were created by the controller but whose
+// objects are gone, e.g. because the finalizer was removed by hand, and reports or deletes them.
+type GarbageCollector struct {
+	KetoClient KetoClient
+	Client     client.Reader
+	Log        logr.Logger
+	Mode       GCMode
+	// Interval between two runs, defaults to an hour
+	Interval time.Duration
+	// IDPrefix, if set, is the prefix of all IDs owned by the controller. Otherwise IDs shaped like
+	// namespace:name are owned, which may include policies and roles created by anybody else.
+	IDPrefix string
+	// KetoHealth, if set, is checked before every run
+	KetoHealth *KetoHealth
+	// Recorder, if set, records events on the namespaces of orphans with IDs shaped like namespace:name
+	Recorder record.EventRecorder
+	// Retained, if set, are the policies and roles kept in ORY Keto by the deletion policy Retain, which
+	// aren't orphans. Delete mode requires it.
+	Retained *RetainedLocations
+}
+
+// Start runs the garbage collection every interval until stop is closed. It only returns then, since the
+// manager stops once any of its runnables returns.
+func (gc *GarbageCollector) Start(stop <-chan struct{}) error {
+	if gc.Mode == GCOff {
+		<-stop
+		return nil
+	}
+
+	interval := gc.Interval
+	if interval == 0 {
+		interval = defaultGCInterval
+	}
+
+	gc.Log.Info("collecting orphaned policies and roles", "mode", gc.Mode, "interval", interval)
+	wait.Until(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		defer cancel()
+		if err := gc.Run(ctx); err != nil {
+			gc.Log.Error(err, "unable to collect orphaned policies and roles")
+		}
+	}, interval, stop)
+	return nil
+}
+
+// Run collects the orphaned policies and roles once
+func (gc *GarbageCollector) Run(ctx context.Context) error {
+	if gc.KetoHealth != nil {
+		if err := gc.KetoHealth.Check(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := gc.collectPolicies(ctx); err != nil {
+		return err
+	}
+	return gc.collectRoles(ctx)
+}
+
+// collectPolicies lists ORY Keto before the objects, so that every policy the controller created before
+// the listing belongs to an object which is listed too
+func (gc *GarbageCollector) collectPolicies(ctx context.Context) error {
+	var ids []ketoLocation
+	for _, flavour := range ketoFlavours {
+		policies, err := gc.KetoClient.ListPolicy(ctx, flavour)
+		if err != nil {
+			return err
+		}
+		for _, p := range policies {
+			ids = append(ids, ketoLocation{flavour, p.Id})
+		}
+	}
+
+	var list ketov1alpha2.PolicyList
+	if err := gc.Client.List(ctx, &list); err != nil {
+		return err
+	}
+	claimed, err := gc.retained(ctx, "policy")
+	if err != nil {
+		return err
+	}
+	for i := range list.Items {
+		claimed[appliedPolicyLocation(&list.Items[i])] = true
+		claimed[desiredPolicyLocation(&list.Items[i])] = true
+	}
+
+	orphans := gc.orphans(ids, claimed)
+	orphanedObjects.WithLabelValues("policy").Set(float64(len(orphans)))
+	for _, orphan := range orphans {
+		if err := gc.collect(ctx, "policy", orphan, gc.KetoClient.DeletePolicy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectRoles lists ORY Keto before the objects, see collectPolicies
+func (gc *GarbageCollector) collectRoles(ctx context.Context) error {
+	var ids []ketoLocation
+	for _, flavour := range ketoFlavours {
+		roles, err := gc.KetoClient.ListRole(ctx, flavour)
+		if err != nil {
+			return err
+		}
+		for _, role := range roles {
+			ids = append(ids, ketoLocation{flavour, role.Id})
+		}
+	}
+
+	var list ketov1alpha2.RoleList
+	if err := gc.Client.List(ctx, &list); err != nil {
+		return err
+	}
+	claimed, err := gc.retained(ctx, "role")
+	if err != nil {
+		return err
+	}
+	for i := range list.Items {
+		claimed[appliedRoleLocation(&list.Items[i])] = true
+		claimed[desiredRoleLocation(&list.Items[i])] = true
+	}
+
+	orphans := gc.orphans(ids, claimed)
+	orphanedObjects.WithLabelValues("role").Set(float64(len(orphans)))
+	for _, orphan := range orphans {
+		if err := gc.collect(ctx, "role", orphan, gc.KetoClient.DeleteRole); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retained returns the locations of the resource kept in ORY Keto by the deletion policy Retain, they are
+// read after the objects, so that objects retained before they were gone are seen
+func (gc *GarbageCollector) retained(ctx context.Context, resource string) (map[ketoLocation]bool, error) {
+	if gc.Retained == nil {
+		if gc.Mode == GCDelete {
+			return nil, fmt.Errorf("deleting orphans requires the retained policies and roles")
+		}
+		return map[ketoLocation]bool{}, nil
+	}
+	return gc.Retained.List(ctx, resource)
+}
+
+// orphans returns the locations of ids which the controller owns but no object claims
+func (gc *GarbageCollector) orphans(ids []ketoLocation, claimed map[ketoLocation]bool) []ketoLocation {
+	var orphans []ketoLocation
+	for _, id := range ids {
+		if gc.owns(id.id) && !claimed[id] {
+			orphans = append(orphans, id)
+		}
+	}
+	return orphans
+}
+
+// owns tells whether id is in the ownership space of the controller
+func (gc *GarbageCollector) owns(id string) bool {
+	if gc.IDPrefix != "" {
+		return strings.HasPrefix(id, gc.IDPrefix)
+	}
+	_, _, ok := ketov1alpha2.ParseGeneratedId(id)
+	return ok
+}
+
+// collect reports the orphan and deletes it in delete mode
+func (gc *GarbageCollector) collect(ctx context.Context, resource string, orphan ketoLocation, remove func(context.Context, keto.Flavour, string) error) error {
+	if gc.Mode != GCDelete {
+		gc.Log.Info(fmt.Sprintf("found orphaned %s", resource), "flavour", orphan.flavour, "id", orphan.id)
+		gc.event(orphan, corev1.EventTypeWarning, ReasonOrphanFound, fmt.Sprintf("%s %s in ORY Keto has no %s object", resource, orphan.key(), resource))
+		return nil
+	}
+
+	if err := remove(ctx, orphan.flavour, orphan.id); err != nil {
+		return err
+	}
+	gc.Log.Info(fmt.Sprintf("deleted orphaned %s", resource), "flavour", orphan.flavour, "id", orphan.id)
+	orphansDeleted.WithLabelValues(resource).Inc()
+	gc.event(orphan, corev1.EventTypeNormal, ReasonOrphanDeleted, fmt.Sprintf("deleted %s %s from ORY Keto as it has no %s object", resource, orphan.key(), resource))
+	return nil
+}
+
+// event records an event on the namespace the orphan was generated for, orphans with other IDs only show
+// up in the logs and metrics
+func (gc *GarbageCollector) event(orphan ketoLocation, eventType, reason, message string) {
+	if gc.Recorder == nil {
+		return
+	}
+	namespace, _, ok := ketov1alpha2.ParseGeneratedId(strings.TrimPrefix(orphan.id, gc.IDPrefix))
+	if !ok {
+		return
+	}
+	gc.Recorder.Event(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}, eventType, reason, message)
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestGarbageCollector(mode GCMode, ketoClient *fakeKetoClient, objs ...runtime.Object) *GarbageCollector {
+	return &GarbageCollector{
+		KetoClient: ketoClient,
+		Client:     fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+		Log:        ctrl.Log.WithName("test"),
+		Mode:       mode,
+	}
+}
+
+func TestGarbageCollector(t *testing.T) {
+
+	orphan := ketoLocation{keto.Exact, "default:orphan"}
+	claimed := ketoLocation{keto.Exact, "default:readers"}
+	foreign := ketoLocation{keto.Exact, "books"}
+
+	newKetoClient := func() *fakeKetoClient {
+		ketoClient := newFakeKetoClient()
+		for _, location := range []ketoLocation{orphan, claimed, foreign} {
+			ketoClient.policies[location] = &keto.PolicyJSON{Id: location.id}
+		}
+		ketoClient.roles[orphan] = &keto.Role{Id: orphan.id}
+		return ketoClient
+	}
+
+	t.Run("reports orphans in report mode", func(t *testing.T) {
+
+		//given
+		ketoClient := newKetoClient()
+		gc := newTestGarbageCollector(GCReport, ketoClient, testPolicy())
+		recorder := record.NewFakeRecorder(100)
+		gc.Recorder = recorder
+
+		//when
+		err := gc.Run(context.Background())
+
+		//then
+		require.NoError(t, err)
+		assert.Equal(t, 0, ketoClient.writes)
+		assert.Equal(t, float64(1), metricValue(t, orphanedObjects.WithLabelValues("policy")))
+		assert.Equal(t, float64(1), metricValue(t, orphanedObjects.WithLabelValues("role")))
+		assert.Equal(t, []string{
+			"Warning OrphanFound policy exact/default:orphan in ORY Keto has no policy object",
+			"Warning OrphanFound role exact/default:orphan in ORY Keto has no role object",
+		}, events(recorder))
+	})
+
+	t.Run("deletes orphans in delete mode", func(t *testing.T) {
+
+		//given
+		ketoClient := newKetoClient()
+		gc := newTestGarbageCollector(GCDelete, ketoClient, testPolicy())
+		gc.Retained = &RetainedLocations{Reader: gc.Client, ConfigMap: retainedConfigMap}
+		recorder := record.NewFakeRecorder(100)
+		gc.Recorder = recorder
+		before := metricValue(t, orphansDeleted.WithLabelValues("policy"))
+
+		//when
+		err := gc.Run(context.Background())
+
+		//then
+		require.NoError(t, err)
+		assert.Equal(t, map[ketoLocation]*keto.PolicyJSON{claimed: {Id: claimed.id}, foreign: {Id: foreign.id}}, ketoClient.policies)
+		assert.Empty(t, ketoClient.roles)
+		assert.Equal(t, before+1, metricValue(t, orphansDeleted.WithLabelValues("policy")))
+		assert.Equal(t, []string{
+			"Normal OrphanDeleted deleted policy exact/default:orphan from ORY Keto as it has no policy object",
+			"Normal OrphanDeleted deleted role exact/default:orphan from ORY Keto as it has no role object",
+		}, events(recorder))
+	})
+
+	t.Run("only owns IDs with its prefix", func(t *testing.T) {
+
+		//given
+		ketoClient := newKetoClient()
+		gc := newTestGarbageCollector(GCDelete, ketoClient)
+		gc.Retained = &RetainedLocations{Reader: gc.Client, ConfigMap: retainedConfigMap}
+		gc.IDPrefix = "books"
+
+		//when
+		err := gc.Run(context.Background())
+
+		//then
+		require.NoError(t, err)
+		assert.Equal(t, map[ketoLocation]*keto.PolicyJSON{orphan: {Id: orphan.id}, claimed: {Id: claimed.id}}, ketoClient.policies)
+	})
+}
+
+func TestGarbageCollectorRetained(t *testing.T) {
+
+	t.Run("leaves retained policies and roles in delete mode", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[ketoLocation{keto.Exact, "default:kept"}] = &keto.PolicyJSON{Id: "default:kept"}
+		ketoClient.policies[ketoLocation{keto.Exact, "default:orphan"}] = &keto.PolicyJSON{Id: "default:orphan"}
+		ketoClient.roles[ketoLocation{keto.Glob, "default:kept"}] = &keto.Role{Id: "default:kept"}
+		retained := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: retainedConfigMap.Namespace, Name: retainedConfigMap.Name},
+			Data:       map[string]string{"policy": "exact/default:kept", "role": "glob/default:kept"},
+		}
+		gc := newTestGarbageCollector(GCDelete, ketoClient, retained)
+		gc.Retained = &RetainedLocations{Reader: gc.Client, ConfigMap: retainedConfigMap}
+
+		//when
+		err := gc.Run(context.Background())
+
+		//then
+		require.NoError(t, err)
+		assert.Equal(t, map[ketoLocation]*keto.PolicyJSON{{keto.Exact, "default:kept"}: {Id: "default:kept"}}, ketoClient.policies)
+		assert.Contains(t, ketoClient.roles, ketoLocation{keto.Glob, "default:kept"})
+	})
+
+	t.Run("leaves merged roles with members of others once their object is gone", func(t *testing.T) {
+
+		//given
+		exact := ketoLocation{keto.Exact, "default:readers"}
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"alice", "bob"}}
+		now := metav1.Now()
+		role := testRole("alice")
+		role.Spec.MembershipMode = ketov1alpha2.MembershipMerge
+		role.Spec.DeletionPolicy = ketov1alpha2.DeletionPolicyDelete
+		role.Finalizers = []string{FinalizerName}
+		role.DeletionTimestamp = &now
+		role.Status.ManagedMembers = []string{"alice"}
+		r, _ := newTestRoleReconciler(ketoClient, role)
+		r.Retained = newTestRetainedLocations(r.Client)
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+		require.NoError(t, r.Delete(context.Background(), &reconciled))
+		gc := newTestGarbageCollector(GCDelete, ketoClient)
+		gc.Client = r.Client
+		gc.Retained = r.Retained
+
+		//when
+		err := gc.Run(context.Background())
+
+		//then
+		require.NoError(t, err)
+		require.Contains(t, ketoClient.roles, exact)
+		assert.Equal(t, []string{"bob"}, ketoClient.roles[exact].Members)
+	})
+
+	t.Run("refuses to delete without the retained policies and roles", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[ketoLocation{keto.Exact, "default:orphan"}] = &keto.PolicyJSON{Id: "default:orphan"}
+		gc := newTestGarbageCollector(GCDelete, ketoClient)
+
+		//when
+		err := gc.Run(context.Background())
+
+		//then
+		assert.Error(t, err)
+		assert.Contains(t, ketoClient.policies, ketoLocation{keto.Exact, "default:orphan"})
+	})
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	defaultKetoHealthInterval = 5 * time.Second
+	defaultKetoHealthTimeout  = 5 * time.Second
+
+	// ketoUnavailableRequeueAfter is how long reconcilers wait before trying again while ORY Keto is unavailable
+	ketoUnavailableRequeueAfter = 15 * time.Second
+)
+
+// KetoHealth tracks whether ORY Keto is alive and ready, asking it at most once per Interval
+// so that reconcilers and probes can share the result.
+type KetoHealth struct {
+	KetoClient KetoClient
+	Interval   time.Duration
+	// Timeout bounds a single probe of ORY Keto, defaults to 5 seconds
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+	// probing is closed once the running probe is done, nil if none is running
+	probing chan struct{}
+}
+
+// Check returns the reason ORY Keto is unavailable, or nil if it is ready. ORY Keto is probed without
+// holding the lock and independent of ctx, callers giving up while it is probed get the error of ctx,
+// which isn't taken for an outage of ORY Keto.
+func (h *KetoHealth) Check(ctx context.Context) error {
+	interval := h.Interval
+	if interval == 0 {
+		interval = defaultKetoHealthInterval
+	}
+
+	h.mu.Lock()
+	if !h.checked.IsZero() && time.Since(h.checked) < interval {
+		err := h.err
+		h.mu.Unlock()
+		return err
+	}
+	if h.probing == nil {
+		h.probing = make(chan struct{})
+		go h.probe(h.probing)
+	}
+	probing := h.probing
+	h.mu.Unlock()
+
+	select {
+	case <-probing:
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return h.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// probe asks ORY Keto whether it is alive and ready and records the answer, closing done afterwards
+func (h *KetoHealth) probe(done chan struct{}) {
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = defaultKetoHealthTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := h.KetoClient.Health(ctx)
+	if err == nil {
+		err = h.KetoClient.Ready(ctx)
+	}
+
+	h.mu.Lock()
+	h.err = err
+	h.checked = time.Now()
+	h.probing = nil
+	h.mu.Unlock()
+	close(done)
+}
+
+// HealthServer serves the liveness and readiness probes of the manager. Liveness only reflects the manager
+// itself, so that an outage of ORY Keto doesn't restart the operator, while readiness follows ORY Keto.
+type HealthServer struct {
+	Addr       string
+	KetoHealth *KetoHealth
+	Log        logr.Logger
+}
+
+// NeedLeaderElection makes the probes available on every replica, not only on the leader
+func (s *HealthServer) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *HealthServer) Start(stop <-chan struct{}) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		if err := s.KetoHealth.Check(req.Context()); err != nil {
+			http.Error(w, fmt.Sprintf("ORY Keto is unavailable: %s", errorDescription(err)), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			s.Log.Error(err, "unable to shut down health server")
+		}
+	}()
+
+	s.Log.Info("serving health probes", "addr", s.Addr)
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// probedKetoClient answers health probes with health, counting them
+type probedKetoClient struct {
+	*fakeKetoClient
+	health func(ctx context.Context) error
+	probes int32
+}
+
+func (k *probedKetoClient) Health(ctx context.Context) error {
+	atomic.AddInt32(&k.probes, 1)
+	return k.health(ctx)
+}
+
+func TestKetoHealth(t *testing.T) {
+
+	t.Run("caches the outcome for the interval", func(t *testing.T) {
+
+		//given
+		ketoClient := &probedKetoClient{fakeKetoClient: newFakeKetoClient(), health: func(context.Context) error { return fmt.Errorf("down") }}
+		health := &KetoHealth{KetoClient: ketoClient, Interval: time.Hour}
+
+		//when
+		first := health.Check(context.Background())
+		second := health.Check(context.Background())
+
+		//then
+		assert.EqualError(t, first, "down")
+		assert.EqualError(t, second, "down")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&ketoClient.probes))
+	})
+
+	t.Run("doesn't take a cancelled caller for an outage", func(t *testing.T) {
+
+		//given
+		release := make(chan struct{})
+		ketoClient := &probedKetoClient{fakeKetoClient: newFakeKetoClient(), health: func(context.Context) error {
+			<-release
+			return nil
+		}}
+		health := &KetoHealth{KetoClient: ketoClient, Interval: time.Hour}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		//when
+		cancelled := health.Check(ctx)
+		close(release)
+		later := health.Check(context.Background())
+
+		//then
+		assert.Equal(t, context.Canceled, cancelled)
+		assert.NoError(t, later)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&ketoClient.probes))
+	})
+
+	t.Run("bounds a slow probe by its own timeout", func(t *testing.T) {
+
+		//given
+		ketoClient := &probedKetoClient{fakeKetoClient: newFakeKetoClient(), health: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+		health := &KetoHealth{KetoClient: ketoClient, Interval: time.Hour, Timeout: 10 * time.Millisecond}
+
+		//when
+		err := health.Check(context.Background())
+
+		//then
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+
+	t.Run("shares a running probe between callers", func(t *testing.T) {
+
+		//given
+		release := make(chan struct{})
+		ketoClient := &probedKetoClient{fakeKetoClient: newFakeKetoClient(), health: func(context.Context) error {
+			<-release
+			return nil
+		}}
+		health := &KetoHealth{KetoClient: ketoClient, Interval: time.Hour}
+		results := make(chan error, 3)
+
+		//when
+		for i := 0; i < 3; i++ {
+			go func() { results <- health.Check(context.Background()) }()
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+
+		//then
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, <-results)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&ketoClient.probes))
+	})
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-logr/logr"
-	ketov1alpha1 "github.com/ory/keto-maester/api/v1alpha1"
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -34,16 +37,40 @@ func removeString(slice []string, s string) (result []string) {
 	return
 }
 
+// subtractStrings returns the items of slice that are not in other
+func subtractStrings(slice, other []string) (result []string) {
+	for _, item := range slice {
+		if !containsString(other, item) {
+			result = append(result, item)
+		}
+	}
+	return
+}
+
+// equalStrings tells whether both slices hold the same items in the same order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 type ReconcilerInterface interface {
 	GetLog() logr.Logger
 	GetResource() string
+	GetRecorder() record.EventRecorder
 
 	Status() client.StatusWriter
 }
 
 type WithStatus interface {
-	SetReconciliationError(err ketov1alpha1.ReconciliationError)
 	SetObservedGeneration(generation int64)
+	SetCondition(condition ketov1alpha2.Condition)
 
 	GetGeneration() int64
 	GetName() string
@@ -54,13 +81,83 @@ type WithStatus interface {
 
 func updateReconciliationStatusError(ctx context.Context, r ReconcilerInterface, obj WithStatus, err error) error {
 	r.GetLog().Error(err, fmt.Sprintf("error processing %s %s/%s ", r.GetResource(), obj.GetName(), obj.GetNamespace()), r.GetResource(), "register")
-	obj.SetReconciliationError(ketov1alpha1.ReconciliationError{
-		Description: err.Error(),
-	})
+	setSyncConditions(obj, errorReason(err), errorDescription(err))
+	recordEvent(r, obj, corev1.EventTypeWarning, errorReason(err), errorDescription(err))
+
+	return updateStatus(ctx, r, obj)
+}
+
+// updateKetoStatusError records an error returned by ORY Keto in the status of obj. Errors that may go away
+// on their own are returned so that the object is requeued, while requests Keto rejected are not retried
+// until the object changes.
+func updateKetoStatusError(ctx context.Context, r ReconcilerInterface, obj WithStatus, err error) error {
+	if statusErr := updateReconciliationStatusError(ctx, r, obj, err); statusErr != nil {
+		return statusErr
+	}
+
+	if _, isAPIError := keto.AsAPIError(err); !isAPIError || keto.IsRetryable(err) || keto.IsConflict(err) {
+		return err
+	}
+	return nil
+}
+
+// updateKetoUnavailableStatus records that ORY Keto can't be reached, so that the object isn't reported
+// with whichever request happened to fail first
+func updateKetoUnavailableStatus(ctx context.Context, r ReconcilerInterface, obj WithStatus, err error) error {
+	r.GetLog().Info(fmt.Sprintf("ORY Keto is unavailable, postponing %s %s/%s", r.GetResource(), obj.GetName(), obj.GetNamespace()), "reason", errorDescription(err))
+	setSyncConditions(obj, ketov1alpha2.ReasonKetoUnavailable, fmt.Sprintf("ORY Keto is unavailable: %s", errorDescription(err)))
+	recordEvent(r, obj, corev1.EventTypeWarning, ketov1alpha2.ReasonKetoUnavailable, fmt.Sprintf("ORY Keto is unavailable: %s", errorDescription(err)))
 
 	return updateStatus(ctx, r, obj)
 }
 
+// errorDescription prefers the description ORY Keto gave for a failed request over the raw error
+func errorDescription(err error) string {
+	if apiErr, ok := keto.AsAPIError(err); ok {
+		return fmt.Sprintf("%s (status code %d)", apiErr.Description(), apiErr.StatusCode)
+	}
+	return err.Error()
+}
+
+// errorReason classifies err for the reason of the conditions
+func errorReason(err error) string {
+	apiErr, ok := keto.AsAPIError(err)
+	switch {
+	case !ok:
+		return ketov1alpha2.ReasonReconcileError
+	case keto.IsRetryable(apiErr) || keto.IsConflict(apiErr):
+		return ketov1alpha2.ReasonKetoError
+	default:
+		return ketov1alpha2.ReasonKetoRejected
+	}
+}
+
+// setSyncConditions sets the Ready, Synced and Degraded conditions of obj after a reconciliation.
+// An empty message means the object was applied to ORY Keto.
+func setSyncConditions(obj WithStatus, reason, message string) {
+	synced, degraded := ketov1alpha2.ConditionTrue, ketov1alpha2.ConditionFalse
+	if message != "" {
+		synced, degraded = ketov1alpha2.ConditionFalse, ketov1alpha2.ConditionTrue
+	}
+
+	for _, c := range []struct {
+		conditionType ketov1alpha2.ConditionType
+		status        ketov1alpha2.ConditionStatus
+	}{
+		{ketov1alpha2.ConditionReady, synced},
+		{ketov1alpha2.ConditionSynced, synced},
+		{ketov1alpha2.ConditionDegraded, degraded},
+	} {
+		obj.SetCondition(ketov1alpha2.Condition{
+			Type:               c.conditionType,
+			Status:             c.status,
+			ObservedGeneration: obj.GetGeneration(),
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+}
+
 func updateStatus(ctx context.Context, r ReconcilerInterface, obj WithStatus) error {
 	obj.SetObservedGeneration(obj.GetGeneration())
 
@@ -72,6 +169,19 @@ func updateStatus(ctx context.Context, r ReconcilerInterface, obj WithStatus) er
 }
 
 func ensureEmptyStatusError(ctx context.Context, r ReconcilerInterface, obj WithStatus) error {
-	obj.SetReconciliationError(ketov1alpha1.ReconciliationError{})
+	setSyncConditions(obj, ketov1alpha2.ReasonSynced, "")
 	return updateStatus(ctx, r, obj)
 }
+
+// GetRecorder returns the recorder of events on the reconciled objects, which may be nil
+func (r *Reconciler) GetRecorder() record.EventRecorder {
+	return r.Recorder
+}
+
+// reconcileContext returns the context a single reconciliation runs with, bounded by the configured timeout.
+func (r *Reconciler) reconcileContext() (context.Context, context.CancelFunc) {
+	if r.Timeout > 0 {
+		return context.WithTimeout(context.Background(), r.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func init() {
+	// the fake client decodes with the client-go scheme
+	if err := ketov1alpha2.AddToScheme(scheme.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+// fakeKetoClient keeps policies and roles in memory, per flavour like ORY Keto does
+type fakeKetoClient struct {
+	policies map[ketoLocation]*keto.PolicyJSON
+	roles    map[ketoLocation]*keto.Role
+
+	// err, if set, is returned by every request
+	err error
+	// writes counts the requests which change anything
+	writes int
+}
+
+var _ KetoClient = &fakeKetoClient{}
+
+func newFakeKetoClient() *fakeKetoClient {
+	return &fakeKetoClient{policies: map[ketoLocation]*keto.PolicyJSON{}, roles: map[ketoLocation]*keto.Role{}}
+}
+
+func (k *fakeKetoClient) GetPolicy(ctx context.Context, flavour keto.Flavour, id string) (*keto.PolicyJSON, bool, error) {
+	if k.err != nil {
+		return nil, false, k.err
+	}
+	p, ok := k.policies[ketoLocation{flavour, id}]
+	if !ok {
+		return nil, false, nil
+	}
+	copied := *p
+	return &copied, true, nil
+}
+
+func (k *fakeKetoClient) ListPolicy(ctx context.Context, flavour keto.Flavour) ([]*keto.PolicyJSON, error) {
+	if k.err != nil {
+		return nil, k.err
+	}
+	var list []*keto.PolicyJSON
+	for location, p := range k.policies {
+		if location.flavour == flavour {
+			copied := *p
+			list = append(list, &copied)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	return list, nil
+}
+
+func (k *fakeKetoClient) UpsertPolicy(ctx context.Context, flavour keto.Flavour, o *keto.PolicyJSON) (*keto.PolicyJSON, error) {
+	if k.err != nil {
+		return nil, k.err
+	}
+	k.writes++
+	copied := *o
+	k.policies[ketoLocation{flavour, o.Id}] = &copied
+	return o, nil
+}
+
+func (k *fakeKetoClient) DeletePolicy(ctx context.Context, flavour keto.Flavour, id string) error {
+	if k.err != nil {
+		return k.err
+	}
+	k.writes++
+	delete(k.policies, ketoLocation{flavour, id})
+	return nil
+}
+
+func (k *fakeKetoClient) GetRole(ctx context.Context, flavour keto.Flavour, id string) (*keto.Role, bool, error) {
+	if k.err != nil {
+		return nil, false, k.err
+	}
+	role, ok := k.roles[ketoLocation{flavour, id}]
+	if !ok {
+		return nil, false, nil
+	}
+	return &keto.Role{Id: role.Id, Members: append([]string{}, role.Members...)}, true, nil
+}
+
+func (k *fakeKetoClient) ListRole(ctx context.Context, flavour keto.Flavour) ([]*keto.Role, error) {
+	if k.err != nil {
+		return nil, k.err
+	}
+	var list []*keto.Role
+	for location, role := range k.roles {
+		if location.flavour == flavour {
+			list = append(list, &keto.Role{Id: role.Id, Members: append([]string{}, role.Members...)})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	return list, nil
+}
+
+func (k *fakeKetoClient) UpsertRole(ctx context.Context, flavour keto.Flavour, o *keto.Role) (*keto.Role, error) {
+	if k.err != nil {
+		return nil, k.err
+	}
+	k.writes++
+	k.roles[ketoLocation{flavour, o.Id}] = &keto.Role{Id: o.Id, Members: append([]string{}, o.Members...)}
+	return o, nil
+}
+
+func (k *fakeKetoClient) DeleteRole(ctx context.Context, flavour keto.Flavour, id string) error {
+	if k.err != nil {
+		return k.err
+	}
+	k.writes++
+	delete(k.roles, ketoLocation{flavour, id})
+	return nil
+}
+
+func (k *fakeKetoClient) AddRoleMembers(ctx context.Context, flavour keto.Flavour, id string, members []string) (*keto.Role, error) {
+	if k.err != nil {
+		return nil, k.err
+	}
+	k.writes++
+	role, ok := k.roles[ketoLocation{flavour, id}]
+	if !ok {
+		role = &keto.Role{Id: id}
+		k.roles[ketoLocation{flavour, id}] = role
+	}
+	for _, member := range members {
+		if !containsString(role.Members, member) {
+			role.Members = append(role.Members, member)
+		}
+	}
+	return role, nil
+}
+
+func (k *fakeKetoClient) RemoveRoleMember(ctx context.Context, flavour keto.Flavour, id, member string) error {
+	if k.err != nil {
+		return k.err
+	}
+	k.writes++
+	if role, ok := k.roles[ketoLocation{flavour, id}]; ok {
+		role.Members = removeString(role.Members, member)
+	}
+	return nil
+}
+
+func (k *fakeKetoClient) Health(ctx context.Context) error {
+	return k.err
+}
+
+func (k *fakeKetoClient) Ready(ctx context.Context) error {
+	return k.err
+}
+
+func (k *fakeKetoClient) IsAllowed(ctx context.Context, flavour keto.Flavour, subject, action, resource string, requestContext map[string]interface{}) (*keto.AuthorizationResult, error) {
+	return nil, k.err
+}
+
+// fakeController only records the kinds watched for role members
+type fakeController struct {
+	watched []source.Source
+}
+
+func (c *fakeController) Reconcile(reconcile.Request) (reconcile.Result, error) {
+	return reconcile.Result{}, nil
+}
+
+func (c *fakeController) Watch(src source.Source, eventhandler handler.EventHandler, predicates ...predicate.Predicate) error {
+	c.watched = append(c.watched, src)
+	return nil
+}
+
+func (c *fakeController) Start(stop <-chan struct{}) error {
+	return nil
+}
+
+// newTestReconciler returns a reconciler of the objects, which records its events in the returned recorder
+func newTestReconciler(ketoClient *fakeKetoClient, objs ...runtime.Object) (*Reconciler, *record.FakeRecorder) {
+	recorder := record.NewFakeRecorder(100)
+	return &Reconciler{
+		Client:     fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+		Log:        ctrl.Log.WithName("test"),
+		KetoClient: ketoClient,
+		Recorder:   recorder,
+	}, recorder
+}
+
+// newTestRoleReconciler returns a role reconciler of the objects, see newTestReconciler
+func newTestRoleReconciler(ketoClient *fakeKetoClient, objs ...runtime.Object) (*KetoRoleReconciler, *record.FakeRecorder) {
+	reconciler, recorder := newTestReconciler(ketoClient, objs...)
+	return &KetoRoleReconciler{
+		Reconciler:    reconciler,
+		memberWatches: &memberWatches{controller: &fakeController{}, client: reconciler.Client, watched: map[schema.GroupKind]bool{}},
+	}, recorder
+}
+
+// reconcileObject reconciles the object of name and reads it into obj afterwards
+func reconcileObject(t *testing.T, r reconcile.Reconciler, c client.Reader, name types.NamespacedName, obj runtime.Object) {
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+	require.NoError(t, err)
+	require.NoError(t, c.Get(context.Background(), name, obj))
+}
+
+// events drains the events recorded so far, each formatted as "type reason message"
+func events(recorder *record.FakeRecorder) []string {
+	var recorded []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			recorded = append(recorded, event)
+		default:
+			return recorded
+		}
+	}
+}
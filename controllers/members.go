@@ -0,0 +1,324 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// roleGroupKind is the kind of the roles managed by this controller
+var roleGroupKind = schema.GroupKind{Group: ketov1alpha2.GroupVersion.Group, Kind: "Role"}
+
+// serviceAccountGroupKind is the default kind of members, which roles may always resolve members from
+var serviceAccountGroupKind = schema.GroupKind{Kind: ketov1alpha2.DefaultMemberKind}
+
+// forbiddenMembersError is returned for member references and selectors the controller isn't allowed to
+// resolve, they fail until the role or the configuration of the controller changes
+type forbiddenMembersError struct {
+	message string
+}
+
+func (e *forbiddenMembersError) Error() string {
+	return e.message
+}
+
+// checkMemberKind tells whether members of role may be resolved from objects of kind in namespace. Roles
+// only see their own namespace and the ones of MemberNamespaces, and besides roles and service accounts
+// only the kinds of MemberKinds, so that they can't copy e.g. secrets of other tenants into ORY Keto.
+func (r *KetoRoleReconciler) checkMemberKind(role *ketov1alpha2.Role, kind ketov1alpha2.MemberKind, namespace string) error {
+	gvk, err := kind.GroupVersionKind()
+	if err != nil {
+		return err
+	}
+
+	allowed := gvk.GroupKind() == roleGroupKind || gvk.GroupKind() == serviceAccountGroupKind
+	for _, k := range r.MemberKinds {
+		allowed = allowed || gvk.GroupKind() == k
+	}
+	if !allowed {
+		return &forbiddenMembersError{fmt.Sprintf("members can't be resolved from %s, the controller only allows %s", gvk.GroupKind(), r.allowedMemberKinds())}
+	}
+
+	if namespace != role.Namespace && !containsString(r.MemberNamespaces, namespace) {
+		return &forbiddenMembersError{fmt.Sprintf("members can't be resolved from namespace %s, the controller only allows the namespace of the role%s", namespace, r.allowedMemberNamespaces())}
+	}
+	return nil
+}
+
+func (r *KetoRoleReconciler) allowedMemberKinds() string {
+	kinds := []string{serviceAccountGroupKind.String(), roleGroupKind.String()}
+	for _, k := range r.MemberKinds {
+		kinds = append(kinds, k.String())
+	}
+	return strings.Join(kinds, ", ")
+}
+
+func (r *KetoRoleReconciler) allowedMemberNamespaces() string {
+	if len(r.MemberNamespaces) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(r.MemberNamespaces, ", ")
+}
+
+// updateForbiddenMembersStatus records that members of role can't be resolved as it refers to objects it
+// isn't allowed to, the role isn't retried until it changes
+func updateForbiddenMembersStatus(ctx context.Context, r ReconcilerInterface, role *ketov1alpha2.Role, err error) error {
+	r.GetLog().Info(fmt.Sprintf("forbidden members of %s %s/%s", r.GetResource(), role.GetName(), role.GetNamespace()), "reason", err.Error())
+	setSyncConditions(role, ketov1alpha2.ReasonMemberRefForbidden, err.Error())
+	recordEvent(r, role, corev1.EventTypeWarning, ketov1alpha2.ReasonMemberRefForbidden, err.Error())
+
+	return updateStatus(ctx, r, role)
+}
+
+// memberWatches watches the kinds role members are resolved from. Roles may refer to any kind, so the
+// watches are only started once a role refers to a kind.
+type memberWatches struct {
+	controller controller.Controller
+	client     client.Reader
+
+	mu      sync.Mutex
+	watched map[schema.GroupKind]bool
+}
+
+// ensure starts watching objects of gvk unless they are watched already
+func (w *memberWatches) ensure(gvk schema.GroupVersionKind) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watched[gvk.GroupKind()] {
+		return nil
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := w.controller.Watch(&source.Kind{Type: obj}, enqueueRolesResolvingFrom(w.client, gvk.GroupKind())); err != nil {
+		return err
+	}
+	w.watched[gvk.GroupKind()] = true
+	return nil
+}
+
+// enqueueRolesResolvingFrom requeues the roles which resolve members from the changed object of kind
+func enqueueRolesResolvingFrom(c client.Reader, kind schema.GroupKind) handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+		var roles ketov1alpha2.RoleList
+		if err := c.List(context.Background(), &roles); err != nil {
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for i := range roles.Items {
+			if resolvesMembersFrom(&roles.Items[i], kind, o.Meta.GetNamespace(), o.Meta.GetName()) {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: roles.Items[i].Namespace, Name: roles.Items[i].Name}})
+			}
+		}
+		return requests
+	})}
+}
+
+// resolvesMembersFrom tells whether the member references or the member selector of role may resolve
+// to the object of kind
+func resolvesMembersFrom(role *ketov1alpha2.Role, kind schema.GroupKind, namespace, name string) bool {
+	for _, ref := range role.Spec.MemberRefs {
+		gvk, err := ref.GroupVersionKind()
+		if err == nil && gvk.GroupKind() == kind && refNamespace(role, ref) == namespace && ref.Name == name {
+			return true
+		}
+	}
+
+	if selector := role.Spec.MemberSelector; selector != nil && role.Namespace == namespace {
+		gvk, err := selector.GroupVersionKind()
+		return err == nil && gvk.GroupKind() == kind
+	}
+	return false
+}
+
+func refNamespace(role *ketov1alpha2.Role, ref ketov1alpha2.MemberRef) string {
+	if ref.Namespace == "" {
+		return role.Namespace
+	}
+	return ref.Namespace
+}
+
+// resolveMembers resolves the member references and the member selector of role to subjects
+func (r *KetoRoleReconciler) resolveMembers(ctx context.Context, role *ketov1alpha2.Role) ([]string, error) {
+	visited := map[types.NamespacedName]bool{{Namespace: role.Namespace, Name: role.Name}: true}
+	members, err := r.resolveMembersOf(ctx, role, visited)
+	if err != nil {
+		return nil, err
+	}
+	return uniqueSorted(members), nil
+}
+
+// resolveMembersOf resolves the member references and the member selector of role, following transitive
+// memberships of roles which haven't been visited yet
+func (r *KetoRoleReconciler) resolveMembersOf(ctx context.Context, role *ketov1alpha2.Role, visited map[types.NamespacedName]bool) ([]string, error) {
+	var members []string
+
+	for _, ref := range role.Spec.MemberRefs {
+		key := client.ObjectKey{Namespace: refNamespace(role, ref), Name: ref.Name}
+		if err := r.checkMemberKind(role, ref.MemberKind, key.Namespace); err != nil {
+			return nil, err
+		}
+		resolved, err := r.resolveMemberRef(ctx, ref.MemberKind, key, visited)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, resolved...)
+	}
+
+	if selector := role.Spec.MemberSelector; selector != nil {
+		if err := r.checkMemberKind(role, selector.MemberKind, role.Namespace); err != nil {
+			return nil, err
+		}
+		labelSelector, err := metav1.LabelSelectorAsSelector(&selector.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		opts := &client.ListOptions{Namespace: role.Namespace, LabelSelector: labelSelector}
+		resolved, err := r.resolveMemberSelector(ctx, selector.MemberKind, opts, visited)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, resolved...)
+	}
+
+	return members, nil
+}
+
+// resolveMemberRef resolves the object of kind at key, objects which don't exist (yet) aren't members
+// just like bindings to missing service accounts don't grant anything
+func (r *KetoRoleReconciler) resolveMemberRef(ctx context.Context, kind ketov1alpha2.MemberKind, key client.ObjectKey, visited map[types.NamespacedName]bool) ([]string, error) {
+	if kind.IsRole() {
+		var role ketov1alpha2.Role
+		if err := r.Get(ctx, key, &role); err != nil {
+			if apierrs.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return r.roleMembers(ctx, kind, []ketov1alpha2.Role{role}, visited)
+	}
+
+	gvk, err := kind.GroupVersionKind()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.memberWatches.ensure(gvk); err != nil {
+		return nil, err
+	}
+
+	obj := unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := r.Get(ctx, key, &obj); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return objectSubjects(kind, []unstructured.Unstructured{obj})
+}
+
+// resolveMemberSelector resolves the objects of kind matching opts
+func (r *KetoRoleReconciler) resolveMemberSelector(ctx context.Context, kind ketov1alpha2.MemberKind, opts *client.ListOptions, visited map[types.NamespacedName]bool) ([]string, error) {
+	if kind.IsRole() {
+		var roles ketov1alpha2.RoleList
+		if err := r.List(ctx, &roles, client.UseListOptions(opts)); err != nil {
+			return nil, err
+		}
+		return r.roleMembers(ctx, kind, roles.Items, visited)
+	}
+
+	gvk, err := kind.GroupVersionKind()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.memberWatches.ensure(gvk); err != nil {
+		return nil, err
+	}
+
+	list := unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := r.List(ctx, &list, client.UseListOptions(opts)); err != nil {
+		return nil, err
+	}
+	return objectSubjects(kind, list.Items)
+}
+
+// roleMembers returns the members roles contribute, either the roles themselves or their members
+func (r *KetoRoleReconciler) roleMembers(ctx context.Context, kind ketov1alpha2.MemberKind, roles []ketov1alpha2.Role, visited map[types.NamespacedName]bool) ([]string, error) {
+	var members []string
+	for i := range roles {
+		role := &roles[i]
+		if kind.RoleMembership == ketov1alpha2.RoleMembershipNested {
+			members = append(members, role.KetoID())
+			continue
+		}
+
+		key := types.NamespacedName{Namespace: role.Namespace, Name: role.Name}
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+
+		resolved, err := r.resolveMembersOf(ctx, role, visited)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, role.Spec.Members...)
+		members = append(members, resolved...)
+	}
+	return members, nil
+}
+
+// objectSubjects renders the subjects of objs with the subject template of kind
+func objectSubjects(kind ketov1alpha2.MemberKind, objs []unstructured.Unstructured) ([]string, error) {
+	tmpl, err := kind.Template()
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		subject, err := ketov1alpha2.RenderSubject(tmpl, ketov1alpha2.MemberObject{
+			APIVersion:  obj.GetAPIVersion(),
+			Kind:        obj.GetKind(),
+			Namespace:   obj.GetNamespace(),
+			Name:        obj.GetName(),
+			Labels:      obj.GetLabels(),
+			Annotations: obj.GetAnnotations(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, subject)
+	}
+	return subjects, nil
+}
+
+// uniqueSorted returns the distinct items sorted
+func uniqueSorted(items []string) []string {
+	var unique []string
+	for _, item := range items {
+		if !containsString(unique, item) {
+			unique = append(unique, item)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
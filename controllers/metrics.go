@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// driftDetected counts the policies and roles found to differ in ORY Keto from their desired state
+	driftDetected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "keto_maester_drift_detected_total",
+		Help: "Number of times a policy or role in ORY Keto was found to differ from its desired state and re-applied",
+	}, []string{"resource"})
+
+	// orphanedObjects is the number of orphaned policies and roles the last garbage collection found
+	orphanedObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "keto_maester_orphans",
+		Help: "Number of policies and roles in ORY Keto owned by the controller but without an object, as of the last garbage collection",
+	}, []string{"resource"})
+
+	// orphansDeleted counts the orphaned policies and roles the garbage collector deleted
+	orphansDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "keto_maester_orphans_deleted_total",
+		Help: "Number of orphaned policies and roles deleted from ORY Keto by the garbage collector",
+	}, []string{"resource"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(driftDetected, orphanedObjects, orphansDeleted)
+}
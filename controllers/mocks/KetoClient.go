@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	context "context"
 	keto "github.com/ory/keto-maester/keto"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,13 +13,36 @@ type KetoClient struct {
 	mock.Mock
 }
 
-// DeletePolicy provides a mock function with given fields: flavour, id
-func (_m *KetoClient) DeletePolicy(flavour keto.Flavour, id string) error {
-	ret := _m.Called(flavour, id)
+// AddRoleMembers provides a mock function with given fields: ctx, flavour, id, members
+func (_m *KetoClient) AddRoleMembers(ctx context.Context, flavour keto.Flavour, id string, members []string) (*keto.Role, error) {
+	ret := _m.Called(ctx, flavour, id, members)
+
+	var r0 *keto.Role
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour, string, []string) *keto.Role); ok {
+		r0 = rf(ctx, flavour, id, members)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*keto.Role)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, keto.Flavour, string, []string) error); ok {
+		r1 = rf(ctx, flavour, id, members)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeletePolicy provides a mock function with given fields: ctx, flavour, id
+func (_m *KetoClient) DeletePolicy(ctx context.Context, flavour keto.Flavour, id string) error {
+	ret := _m.Called(ctx, flavour, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(keto.Flavour, string) error); ok {
-		r0 = rf(flavour, id)
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour, string) error); ok {
+		r0 = rf(ctx, flavour, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -26,13 +50,13 @@ func (_m *KetoClient) DeletePolicy(flavour keto.Flavour, id string) error {
 	return r0
 }
 
-// DeleteRole provides a mock function with given fields: flavour, id
-func (_m *KetoClient) DeleteRole(flavour keto.Flavour, id string) error {
-	ret := _m.Called(flavour, id)
+// DeleteRole provides a mock function with given fields: ctx, flavour, id
+func (_m *KetoClient) DeleteRole(ctx context.Context, flavour keto.Flavour, id string) error {
+	ret := _m.Called(ctx, flavour, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(keto.Flavour, string) error); ok {
-		r0 = rf(flavour, id)
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour, string) error); ok {
+		r0 = rf(ctx, flavour, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -40,13 +64,13 @@ func (_m *KetoClient) DeleteRole(flavour keto.Flavour, id string) error {
 	return r0
 }
 
-// GetPolicy provides a mock function with given fields: flavour, id
-func (_m *KetoClient) GetPolicy(flavour keto.Flavour, id string) (*keto.PolicyJSON, bool, error) {
-	ret := _m.Called(flavour, id)
+// GetPolicy provides a mock function with given fields: ctx, flavour, id
+func (_m *KetoClient) GetPolicy(ctx context.Context, flavour keto.Flavour, id string) (*keto.PolicyJSON, bool, error) {
+	ret := _m.Called(ctx, flavour, id)
 
 	var r0 *keto.PolicyJSON
-	if rf, ok := ret.Get(0).(func(keto.Flavour, string) *keto.PolicyJSON); ok {
-		r0 = rf(flavour, id)
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour, string) *keto.PolicyJSON); ok {
+		r0 = rf(ctx, flavour, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*keto.PolicyJSON)
@@ -54,15 +78,15 @@ func (_m *KetoClient) GetPolicy(flavour keto.Flavour, id string) (*keto.PolicyJS
 	}
 
 	var r1 bool
-	if rf, ok := ret.Get(1).(func(keto.Flavour, string) bool); ok {
-		r1 = rf(flavour, id)
+	if rf, ok := ret.Get(1).(func(context.Context, keto.Flavour, string) bool); ok {
+		r1 = rf(ctx, flavour, id)
 	} else {
 		r1 = ret.Get(1).(bool)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(keto.Flavour, string) error); ok {
-		r2 = rf(flavour, id)
+	if rf, ok := ret.Get(2).(func(context.Context, keto.Flavour, string) error); ok {
+		r2 = rf(ctx, flavour, id)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -70,13 +94,13 @@ func (_m *KetoClient) GetPolicy(flavour keto.Flavour, id string) (*keto.PolicyJS
 	return r0, r1, r2
 }
 
-// GetRole provides a mock function with given fields: flavour, id
-func (_m *KetoClient) GetRole(flavour keto.Flavour, id string) (*keto.Role, bool, error) {
-	ret := _m.Called(flavour, id)
+// GetRole provides a mock function with given fields: ctx, flavour, id
+func (_m *KetoClient) GetRole(ctx context.Context, flavour keto.Flavour, id string) (*keto.Role, bool, error) {
+	ret := _m.Called(ctx, flavour, id)
 
 	var r0 *keto.Role
-	if rf, ok := ret.Get(0).(func(keto.Flavour, string) *keto.Role); ok {
-		r0 = rf(flavour, id)
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour, string) *keto.Role); ok {
+		r0 = rf(ctx, flavour, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*keto.Role)
@@ -84,15 +108,15 @@ func (_m *KetoClient) GetRole(flavour keto.Flavour, id string) (*keto.Role, bool
 	}
 
 	var r1 bool
-	if rf, ok := ret.Get(1).(func(keto.Flavour, string) bool); ok {
-		r1 = rf(flavour, id)
+	if rf, ok := ret.Get(1).(func(context.Context, keto.Flavour, string) bool); ok {
+		r1 = rf(ctx, flavour, id)
 	} else {
 		r1 = ret.Get(1).(bool)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(keto.Flavour, string) error); ok {
-		r2 = rf(flavour, id)
+	if rf, ok := ret.Get(2).(func(context.Context, keto.Flavour, string) error); ok {
+		r2 = rf(ctx, flavour, id)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -100,13 +124,50 @@ func (_m *KetoClient) GetRole(flavour keto.Flavour, id string) (*keto.Role, bool
 	return r0, r1, r2
 }
 
-// ListPolicy provides a mock function with given fields: flavour
-func (_m *KetoClient) ListPolicy(flavour keto.Flavour) ([]*keto.PolicyJSON, error) {
-	ret := _m.Called(flavour)
+// Health provides a mock function with given fields: ctx
+func (_m *KetoClient) Health(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// IsAllowed provides a mock function with given fields: ctx, flavour, subject, action, resource, requestContext
+func (_m *KetoClient) IsAllowed(ctx context.Context, flavour keto.Flavour, subject string, action string, resource string, requestContext map[string]interface{}) (*keto.AuthorizationResult, error) {
+	ret := _m.Called(ctx, flavour, subject, action, resource, requestContext)
+
+	var r0 *keto.AuthorizationResult
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour, string, string, string, map[string]interface{}) *keto.AuthorizationResult); ok {
+		r0 = rf(ctx, flavour, subject, action, resource, requestContext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*keto.AuthorizationResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, keto.Flavour, string, string, string, map[string]interface{}) error); ok {
+		r1 = rf(ctx, flavour, subject, action, resource, requestContext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPolicy provides a mock function with given fields: ctx, flavour
+func (_m *KetoClient) ListPolicy(ctx context.Context, flavour keto.Flavour) ([]*keto.PolicyJSON, error) {
+	ret := _m.Called(ctx, flavour)
 
 	var r0 []*keto.PolicyJSON
-	if rf, ok := ret.Get(0).(func(keto.Flavour) []*keto.PolicyJSON); ok {
-		r0 = rf(flavour)
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour) []*keto.PolicyJSON); ok {
+		r0 = rf(ctx, flavour)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*keto.PolicyJSON)
@@ -114,8 +175,8 @@ func (_m *KetoClient) ListPolicy(flavour keto.Flavour) ([]*keto.PolicyJSON, erro
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(keto.Flavour) error); ok {
-		r1 = rf(flavour)
+	if rf, ok := ret.Get(1).(func(context.Context, keto.Flavour) error); ok {
+		r1 = rf(ctx, flavour)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -123,13 +184,13 @@ func (_m *KetoClient) ListPolicy(flavour keto.Flavour) ([]*keto.PolicyJSON, erro
 	return r0, r1
 }
 
-// ListRole provides a mock function with given fields: flavour
-func (_m *KetoClient) ListRole(flavour keto.Flavour) ([]*keto.Role, error) {
-	ret := _m.Called(flavour)
+// ListRole provides a mock function with given fields: ctx, flavour
+func (_m *KetoClient) ListRole(ctx context.Context, flavour keto.Flavour) ([]*keto.Role, error) {
+	ret := _m.Called(ctx, flavour)
 
 	var r0 []*keto.Role
-	if rf, ok := ret.Get(0).(func(keto.Flavour) []*keto.Role); ok {
-		r0 = rf(flavour)
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour) []*keto.Role); ok {
+		r0 = rf(ctx, flavour)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*keto.Role)
@@ -137,8 +198,8 @@ func (_m *KetoClient) ListRole(flavour keto.Flavour) ([]*keto.Role, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(keto.Flavour) error); ok {
-		r1 = rf(flavour)
+	if rf, ok := ret.Get(1).(func(context.Context, keto.Flavour) error); ok {
+		r1 = rf(ctx, flavour)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -146,13 +207,41 @@ func (_m *KetoClient) ListRole(flavour keto.Flavour) ([]*keto.Role, error) {
 	return r0, r1
 }
 
-// UpsertPolicy provides a mock function with given fields: flavour, o
-func (_m *KetoClient) UpsertPolicy(flavour keto.Flavour, o *keto.PolicyJSON) (*keto.PolicyJSON, error) {
-	ret := _m.Called(flavour, o)
+// Ready provides a mock function with given fields: ctx
+func (_m *KetoClient) Ready(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveRoleMember provides a mock function with given fields: ctx, flavour, id, member
+func (_m *KetoClient) RemoveRoleMember(ctx context.Context, flavour keto.Flavour, id string, member string) error {
+	ret := _m.Called(ctx, flavour, id, member)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour, string, string) error); ok {
+		r0 = rf(ctx, flavour, id, member)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpsertPolicy provides a mock function with given fields: ctx, flavour, o
+func (_m *KetoClient) UpsertPolicy(ctx context.Context, flavour keto.Flavour, o *keto.PolicyJSON) (*keto.PolicyJSON, error) {
+	ret := _m.Called(ctx, flavour, o)
 
 	var r0 *keto.PolicyJSON
-	if rf, ok := ret.Get(0).(func(keto.Flavour, *keto.PolicyJSON) *keto.PolicyJSON); ok {
-		r0 = rf(flavour, o)
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour, *keto.PolicyJSON) *keto.PolicyJSON); ok {
+		r0 = rf(ctx, flavour, o)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*keto.PolicyJSON)
@@ -160,8 +249,8 @@ func (_m *KetoClient) UpsertPolicy(flavour keto.Flavour, o *keto.PolicyJSON) (*k
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(keto.Flavour, *keto.PolicyJSON) error); ok {
-		r1 = rf(flavour, o)
+	if rf, ok := ret.Get(1).(func(context.Context, keto.Flavour, *keto.PolicyJSON) error); ok {
+		r1 = rf(ctx, flavour, o)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -169,13 +258,13 @@ func (_m *KetoClient) UpsertPolicy(flavour keto.Flavour, o *keto.PolicyJSON) (*k
 	return r0, r1
 }
 
-// UpsertRole provides a mock function with given fields: flavour, o
-func (_m *KetoClient) UpsertRole(flavour keto.Flavour, o *keto.Role) (*keto.Role, error) {
-	ret := _m.Called(flavour, o)
+// UpsertRole provides a mock function with given fields: ctx, flavour, o
+func (_m *KetoClient) UpsertRole(ctx context.Context, flavour keto.Flavour, o *keto.Role) (*keto.Role, error) {
+	ret := _m.Called(ctx, flavour, o)
 
 	var r0 *keto.Role
-	if rf, ok := ret.Get(0).(func(keto.Flavour, *keto.Role) *keto.Role); ok {
-		r0 = rf(flavour, o)
+	if rf, ok := ret.Get(0).(func(context.Context, keto.Flavour, *keto.Role) *keto.Role); ok {
+		r0 = rf(ctx, flavour, o)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*keto.Role)
@@ -183,8 +272,8 @@ func (_m *KetoClient) UpsertRole(flavour keto.Flavour, o *keto.Role) (*keto.Role
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(keto.Flavour, *keto.Role) error); ok {
-		r1 = rf(flavour, o)
+	if rf, ok := ret.Get(1).(func(context.Context, keto.Flavour, *keto.Role) error); ok {
+		r1 = rf(ctx, flavour, o)
 	} else {
 		r1 = ret.Error(1)
 	}
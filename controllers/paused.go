@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// PausedAnnotation set to "true" on a policy, a role or a namespace stops the controller from writing
+// anything to ORY Keto for them, until it is removed
+const PausedAnnotation = "keto.ory.sh/paused"
+
+func hasPausedAnnotation(obj metav1.Object) bool {
+	return obj.GetAnnotations()[PausedAnnotation] == "true"
+}
+
+// pausedBy returns what pauses the reconciliation of obj, the object itself or its namespace, or an
+// empty string if it isn't paused
+func pausedBy(ctx context.Context, c client.Reader, obj metav1.Object) (string, error) {
+	if hasPausedAnnotation(obj) {
+		return "object", nil
+	}
+
+	var namespace corev1.Namespace
+	if err := c.Get(ctx, types.NamespacedName{Name: obj.GetNamespace()}, &namespace); err != nil {
+		if apierrs.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if hasPausedAnnotation(&namespace) {
+		return "namespace", nil
+	}
+	return "", nil
+}
+
+// updatePausedStatus records that obj isn't reconciled because it is paused by, the status is only
+// written when it changes, so that it doesn't trigger another reconciliation
+func updatePausedStatus(ctx context.Context, r ReconcilerInterface, obj WithStatus, conditions []ketov1alpha2.Condition, by string) error {
+	paused := ketov1alpha2.Condition{
+		Type:               ketov1alpha2.ConditionPaused,
+		Status:             ketov1alpha2.ConditionTrue,
+		ObservedGeneration: obj.GetGeneration(),
+		Reason:             ketov1alpha2.ReasonPaused,
+		Message:            fmt.Sprintf("nothing is written to ORY Keto while the %s is annotated with %s", by, PausedAnnotation),
+	}
+	if !conditionChanged(conditions, paused) {
+		return nil
+	}
+
+	r.GetLog().Info(fmt.Sprintf("paused %s %s/%s", r.GetResource(), obj.GetName(), obj.GetNamespace()), "reason", paused.Message)
+	obj.SetCondition(paused)
+	if err := r.Status().Update(ctx, obj); err != nil {
+		r.GetLog().Error(err, fmt.Sprintf("status update failed for %s %s/%s", r.GetResource(), obj.GetName(), obj.GetNamespace()), r.GetResource(), "update status")
+		return err
+	}
+	return nil
+}
+
+// resumeReconciliation marks obj as no longer paused, it returns false if it wasn't paused
+func resumeReconciliation(obj WithStatus, conditions []ketov1alpha2.Condition) bool {
+	if !ketov1alpha2.IsConditionTrue(conditions, ketov1alpha2.ConditionPaused) {
+		return false
+	}
+
+	obj.SetCondition(ketov1alpha2.Condition{
+		Type:               ketov1alpha2.ConditionPaused,
+		Status:             ketov1alpha2.ConditionFalse,
+		ObservedGeneration: obj.GetGeneration(),
+		Reason:             ketov1alpha2.ReasonResumed,
+	})
+	return true
+}
+
+// enqueueNamespaceObjects requeues the objects in a namespace pausing or resuming their reconciliation.
+// Updates map the old and the new namespace, so that removing the annotation is seen as well.
+func enqueueNamespaceObjects(c client.Reader, list func(ctx context.Context, c client.Reader, namespace string) ([]types.NamespacedName, error)) handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+		if !hasPausedAnnotation(o.Meta) {
+			return nil
+		}
+
+		names, err := list(context.Background(), c, o.Meta.GetName())
+		if err != nil {
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(names))
+		for _, name := range names {
+			requests = append(requests, reconcile.Request{NamespacedName: name})
+		}
+		return requests
+	})}
+}
+
+// listPolicyNames lists the policies in namespace
+func listPolicyNames(ctx context.Context, c client.Reader, namespace string) ([]types.NamespacedName, error) {
+	var list ketov1alpha2.PolicyList
+	if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	names := make([]types.NamespacedName, 0, len(list.Items))
+	for _, p := range list.Items {
+		names = append(names, types.NamespacedName{Namespace: p.Namespace, Name: p.Name})
+	}
+	return names, nil
+}
+
+// listRoleNames lists the roles in namespace
+func listRoleNames(ctx context.Context, c client.Reader, namespace string) ([]types.NamespacedName, error) {
+	var list ketov1alpha2.RoleList
+	if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	names := make([]types.NamespacedName, 0, len(list.Items))
+	for _, r := range list.Items {
+		names = append(names, types.NamespacedName{Namespace: r.Namespace, Name: r.Name})
+	}
+	return names, nil
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"testing"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPause(t *testing.T) {
+
+	exact := ketoLocation{keto.Exact, "default:readers"}
+	paused := map[string]string{PausedAnnotation: "true"}
+
+	t.Run("doesn't write paused policies", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		p := testPolicy()
+		p.Annotations = paused
+		r, _ := newTestReconciler(ketoClient, p)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Equal(t, 0, ketoClient.writes)
+		assert.True(t, ketov1alpha2.IsConditionTrue(reconciled.Status.Conditions, ketov1alpha2.ConditionPaused))
+	})
+
+	t.Run("doesn't write roles of paused namespaces", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: roleName.Namespace, Annotations: paused}}
+		r, _ := newTestRoleReconciler(ketoClient, testRole("alice"), namespace)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.Equal(t, 0, ketoClient.writes)
+		condition := ketov1alpha2.FindCondition(reconciled.Status.Conditions, ketov1alpha2.ConditionPaused)
+		require.NotNil(t, condition)
+		assert.Equal(t, ketov1alpha2.ConditionTrue, condition.Status)
+		assert.Contains(t, condition.Message, "namespace")
+	})
+
+	t.Run("keeps deleted paused policies", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id}
+		p := deletedPolicy(ketov1alpha2.DeletionPolicyDelete)
+		p.Annotations = paused
+		r, _ := newTestReconciler(ketoClient, p)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Equal(t, 0, ketoClient.writes)
+		assert.Contains(t, reconciled.Finalizers, FinalizerName)
+	})
+
+	t.Run("checks resumed policies for drift", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id, Subjects: []string{"mallory"}}
+		p := appliedPolicy()
+		p.SetCondition(ketov1alpha2.Condition{Type: ketov1alpha2.ConditionPaused, Status: ketov1alpha2.ConditionTrue, Reason: ketov1alpha2.ReasonPaused})
+		r, recorder := newTestReconciler(ketoClient, p)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Equal(t, []string{"alice"}, ketoClient.policies[exact].Subjects)
+		resumed := ketov1alpha2.FindCondition(reconciled.Status.Conditions, ketov1alpha2.ConditionPaused)
+		require.NotNil(t, resumed)
+		assert.Equal(t, ketov1alpha2.ConditionFalse, resumed.Status)
+		assert.Equal(t, ketov1alpha2.ReasonResumed, resumed.Reason)
+		assert.Contains(t, events(recorder), "Normal DriftCorrected re-applied policy exact/default:readers in ORY Keto")
+	})
+
+	t.Run("records resumed policies which didn't drift", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id, Subjects: []string{"alice"}, Actions: []string{"read"}, Resources: []string{"books"}, Effect: "allow"}
+		p := appliedPolicy()
+		p.SetCondition(ketov1alpha2.Condition{Type: ketov1alpha2.ConditionPaused, Status: ketov1alpha2.ConditionTrue, Reason: ketov1alpha2.ReasonPaused})
+		r, _ := newTestReconciler(ketoClient, p)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Equal(t, 0, ketoClient.writes)
+		assert.False(t, ketov1alpha2.IsConditionTrue(reconciled.Status.Conditions, ketov1alpha2.ConditionPaused))
+	})
+}
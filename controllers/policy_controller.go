@@ -2,30 +2,52 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"github.com/ory/keto-maester/keto"
+	"time"
 
 	"github.com/go-logr/logr"
-	ketov1alpha1 "github.com/ory/keto-maester/api/v1alpha1"
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 type KetoClient interface {
-	GetPolicy(flavour keto.Flavour, id string) (*keto.PolicyJSON, bool, error)
-	ListPolicy(flavour keto.Flavour) ([]*keto.PolicyJSON, error)
-	UpsertPolicy(flavour keto.Flavour, o *keto.PolicyJSON) (*keto.PolicyJSON, error)
-	DeletePolicy(flavour keto.Flavour, id string) error
-
-	GetRole(flavour keto.Flavour, id string) (*keto.Role, bool, error)
-	ListRole(flavour keto.Flavour) ([]*keto.Role, error)
-	UpsertRole(flavour keto.Flavour, o *keto.Role) (*keto.Role, error)
-	DeleteRole(flavour keto.Flavour, id string) error
+	GetPolicy(ctx context.Context, flavour keto.Flavour, id string) (*keto.PolicyJSON, bool, error)
+	ListPolicy(ctx context.Context, flavour keto.Flavour) ([]*keto.PolicyJSON, error)
+	UpsertPolicy(ctx context.Context, flavour keto.Flavour, o *keto.PolicyJSON) (*keto.PolicyJSON, error)
+	DeletePolicy(ctx context.Context, flavour keto.Flavour, id string) error
+
+	GetRole(ctx context.Context, flavour keto.Flavour, id string) (*keto.Role, bool, error)
+	ListRole(ctx context.Context, flavour keto.Flavour) ([]*keto.Role, error)
+	UpsertRole(ctx context.Context, flavour keto.Flavour, o *keto.Role) (*keto.Role, error)
+	DeleteRole(ctx context.Context, flavour keto.Flavour, id string) error
+	AddRoleMembers(ctx context.Context, flavour keto.Flavour, id string, members []string) (*keto.Role, error)
+	RemoveRoleMember(ctx context.Context, flavour keto.Flavour, id, member string) error
+
+	Health(ctx context.Context) error
+	Ready(ctx context.Context) error
+
+	IsAllowed(ctx context.Context, flavour keto.Flavour, subject, action, resource string, requestContext map[string]interface{}) (*keto.AuthorizationResult, error)
 }
 
 type Reconciler struct {
 	KetoClient KetoClient
 	Log        logr.Logger
+	// Timeout bounds a single reconciliation, including all requests to ORY Keto. Zero means no timeout.
+	Timeout time.Duration
+	// KetoHealth, if set, is checked before every reconciliation so that nothing is sent while ORY Keto is unavailable
+	KetoHealth *KetoHealth
+	// Recorder, if set, records events on the reconciled objects
+	Recorder record.EventRecorder
+	// DeletionPolicy applies to objects which don't set their own, empty means Delete
+	DeletionPolicy ketov1alpha2.DeletionPolicy
+	// Retained, if set, records the policies and roles kept in ORY Keto by the deletion policy Retain
+	Retained *RetainedLocations
 	client.Client
 }
 
@@ -43,12 +65,16 @@ func (r KetoPolicyReconciler) GetResource() string {
 
 // +kubebuilder:rbac:groups=keto.ory.sh,resources=policies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=keto.ory.sh,resources=policies/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keto.ory.sh,resources=roles,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
 func (r *KetoPolicyReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	ctx := context.Background()
+	ctx, cancel := r.reconcileContext()
+	defer cancel()
 	_ = r.Log.WithValues(r.GetResource(), req.NamespacedName)
 
-	var policy ketov1alpha1.Policy
+	var policy ketov1alpha2.Policy
 	if err := r.Get(ctx, req.NamespacedName, &policy); err != nil {
 		if apierrs.IsNotFound(err) {
 			//if registerErr := r.removePolicies(ctx, &policy); registerErr != nil {
@@ -59,6 +85,26 @@ func (r *KetoPolicyReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return ctrl.Result{}, err
 	}
 
+	// paused objects are neither applied nor removed, their finalizer keeps them until they are resumed
+	paused, err := pausedBy(ctx, r, &policy)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if paused != "" {
+		return ctrl.Result{}, updatePausedStatus(ctx, r, &policy, policy.Status.Conditions, paused)
+	}
+	resumed := resumeReconciliation(&policy, policy.Status.Conditions)
+
+	if r.KetoHealth != nil {
+		if err := r.KetoHealth.Check(ctx); err != nil {
+			if ctx.Err() != nil {
+				// the reconciliation ran out of time waiting for the probe, which says nothing about ORY Keto
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: ketoUnavailableRequeueAfter}, updateKetoUnavailableStatus(ctx, r, &policy, err)
+		}
+	}
+
 	// examine DeletionTimestamp to determine if object is under deletion
 	if policy.ObjectMeta.DeletionTimestamp.IsZero() {
 		// The object is not being deleted, so if it does not have our finalizer,
@@ -97,48 +143,173 @@ func (r *KetoPolicyReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return ctrl.Result{}, registerErr
 	}
 
+	if resumed {
+		// the policy was compared with ORY Keto again, which isn't recorded in the status unless it differed
+		return ctrl.Result{}, updateStatus(ctx, r, &policy)
+	}
+
 	return ctrl.Result{}, nil
 }
 
 func (r *KetoPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&ketov1alpha1.Policy{}).
+		For(&ketov1alpha2.Policy{}).
+		Watches(&source.Kind{Type: &ketov1alpha2.Policy{}}, enqueueKetoClaimants(mgr.GetClient(), listPolicyClaimants)).
+		Watches(&source.Kind{Type: &ketov1alpha2.Role{}}, enqueuePoliciesReferencing(mgr.GetClient())).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, enqueueNamespaceObjects(mgr.GetClient(), listPolicyNames)).
 		Complete(r)
 }
 
-func (r *KetoPolicyReconciler) upsertPolicy(ctx context.Context, p *ketov1alpha1.Policy) error {
-	_, exists, _ := r.KetoClient.GetPolicy(keto.Exact, ketov1alpha1.GenerateId(p))
-	if exists && p.Generation == p.Status.ObservedGeneration {
-		return nil
+func (r *KetoPolicyReconciler) upsertPolicy(ctx context.Context, p *ketov1alpha2.Policy) error {
+	claimants, err := listPolicyClaimants(ctx, r, p.KetoKey())
+	if err != nil {
+		return err
+	}
+	if owner := ketoIDConflict(p, claimants); owner != nil {
+		return updateKetoIDConflictStatus(ctx, r, p, owner)
+	}
+
+	subjects, resolved, err := r.resolveSubjects(ctx, p)
+	if err != nil {
+		return err
+	}
+	subjectsChanged := !equalStrings(subjects, p.Status.ResolvedSubjects)
+	if len(p.Spec.SubjectRefs) > 0 || ketov1alpha2.FindCondition(p.Status.Conditions, ketov1alpha2.ConditionResolved) != nil {
+		subjectsChanged = subjectsChanged || conditionChanged(p.Status.Conditions, resolved)
+		p.SetCondition(resolved)
 	}
+	p.Status.ResolvedSubjects = subjects
 
-	_, err := r.KetoClient.UpsertPolicy(keto.Flavour(p.Spec.PatternMatching), p.ToPolicyJSON())
+	// the defaulting webhook is optional, so defaults are applied here too
+	defaulted := p.DeepCopy()
+	defaulted.SetDefaults()
 
+	policyJSON, err := defaulted.ToPolicyJSON()
 	if err != nil {
 		return updateReconciliationStatusError(ctx, r, p, err)
 	}
 
-	return ensureEmptyStatusError(ctx, r, p)
+	desired := desiredPolicyLocation(p)
+	current, exists, err := r.KetoClient.GetPolicy(ctx, desired.flavour, desired.id)
+	if err != nil {
+		return updateKetoStatusError(ctx, r, p, err)
+	}
+
+	// a policy which was applied and didn't change since only needs to be written again when it was
+	// changed or deleted in Keto behind our back
+	applied := !subjectsChanged && desired == appliedPolicyLocation(p) && p.Generation == p.Status.ObservedGeneration && ketov1alpha2.IsConditionTrue(p.Status.Conditions, ketov1alpha2.ConditionSynced)
+	drifted := false
+	if applied {
+		if exists && keto.PoliciesEqual(policyJSON, current) {
+			return nil
+		}
+		recordDrift(r, p, exists)
+		drifted = true
+	}
+
+	if _, err := r.KetoClient.UpsertPolicy(ctx, desired.flavour, policyJSON); err != nil {
+		return updateKetoStatusError(ctx, r, p, err)
+	}
+	recordApplied(r, p, desired, exists, drifted)
+
+	// the policy only leaves its previous flavour and ID once it exists under the new ones
+	if previous := appliedPolicyLocation(p); previous != desired {
+		if err := r.deletePolicy(ctx, p, previous); err != nil {
+			return updateKetoStatusError(ctx, r, p, err)
+		}
+	}
+
+	p.Status.Flavour = ketov1alpha2.Flavour(desired.flavour)
+	p.Status.KetoID = desired.id
+	setSyncConditions(p, ketov1alpha2.ReasonSynced, "")
+	if resolved.Status != ketov1alpha2.ConditionTrue {
+		// the policy is applied, but without the subjects of the roles which couldn't be resolved
+		p.SetCondition(ketov1alpha2.Condition{
+			Type:               ketov1alpha2.ConditionReady,
+			Status:             ketov1alpha2.ConditionFalse,
+			ObservedGeneration: p.Generation,
+			Reason:             resolved.Reason,
+			Message:            resolved.Message,
+		})
+	}
+	return updateStatus(ctx, r, p)
 }
 
-func (r *KetoPolicyReconciler) removePolicies(ctx context.Context, p *ketov1alpha1.Policy) error {
+func (r *KetoPolicyReconciler) removePolicies(ctx context.Context, p *ketov1alpha2.Policy) error {
+	// a change of the flavour or ID may have been interrupted before the policy was recorded as moved
+	locations := []ketoLocation{appliedPolicyLocation(p)}
+	if desired := desiredPolicyLocation(p); desired != locations[0] {
+		locations = append(locations, desired)
+	}
 
-	// if a reqired field is empty, that means this is a delete after
-	// the finalizers have done their job, so just return
-	if p.Spec.Effect == "" && p.Spec.PatternMatching == "" {
+	if r.retains(p.Spec.DeletionPolicy) {
+		if err := r.retainLocations(ctx, r.GetResource(), locations); err != nil {
+			return err
+		}
+		recordRetained(r, p, locations[0])
 		return nil
 	}
-	id := ketov1alpha1.GenerateId(p)
-	_, exists, err := r.KetoClient.GetPolicy(keto.Flavour(p.Spec.PatternMatching), id)
-	if err != nil {
-		return err
-	}
 
-	if exists {
-		if err := r.KetoClient.DeletePolicy(keto.Flavour(p.Spec.PatternMatching), id); err != nil {
+	for _, location := range locations {
+		if err := r.deletePolicy(ctx, p, location); err != nil {
 			return err
 		}
 	}
+	return r.releaseLocations(ctx, r.GetResource(), locations)
+}
+
+// deletePolicy deletes the policy stored at location, unless another policy claims it now
+func (r *KetoPolicyReconciler) deletePolicy(ctx context.Context, p *ketov1alpha2.Policy, location ketoLocation) error {
+	claimed, err := claimedByOthers(ctx, r, listPolicyClaimants, p, location.key())
+	if err != nil || claimed {
+		return err
+	}
 
+	_, exists, err := r.KetoClient.GetPolicy(ctx, location.flavour, location.id)
+	if err != nil || !exists {
+		return err
+	}
+	if err := r.KetoClient.DeletePolicy(ctx, location.flavour, location.id); err != nil {
+		return err
+	}
+	recordEvent(r, p, corev1.EventTypeNormal, ReasonDeleted, fmt.Sprintf("deleted policy %s from ORY Keto", location.key()))
 	return nil
 }
+
+// desiredPolicyLocation returns where the policy is to be applied
+func desiredPolicyLocation(p *ketov1alpha2.Policy) ketoLocation {
+	flavour := keto.Flavour(ketov1alpha2.DefaultFlavour)
+	if p.Spec.Flavour != "" {
+		flavour = keto.Flavour(p.Spec.Flavour)
+	}
+	return ketoLocation{flavour: flavour, id: p.KetoID()}
+}
+
+// appliedPolicyLocation returns where the policy was last applied. Policies reconciled before the flavour
+// was recorded were always written with the flavour of their spec.
+func appliedPolicyLocation(p *ketov1alpha2.Policy) ketoLocation {
+	applied := desiredPolicyLocation(p)
+	if p.Status.Flavour != "" {
+		applied.flavour = keto.Flavour(p.Status.Flavour)
+	}
+	if p.Status.KetoID != "" {
+		applied.id = p.Status.KetoID
+	}
+	return applied
+}
+
+// listPolicyClaimants lists the policies claiming key in ORY Keto
+func listPolicyClaimants(ctx context.Context, c client.Reader, key string) ([]ketoClaimant, error) {
+	var list ketov1alpha2.PolicyList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	var claimants []ketoClaimant
+	for i := range list.Items {
+		if list.Items[i].KetoKey() == key {
+			claimants = append(claimants, &list.Items[i])
+		}
+	}
+	return claimants, nil
+}
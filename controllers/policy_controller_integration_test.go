@@ -5,7 +5,7 @@ import (
 	"fmt"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	keto1alpha1 "github.com/ory/keto-maester/api/v1alpha1"
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
 	"github.com/ory/keto-maester/controllers"
 	"github.com/ory/keto-maester/controllers/mocks"
 	"github.com/ory/keto-maester/keto"
@@ -43,7 +43,7 @@ var _ = Describe("Policy Controller", func() {
 				expectedRequest := &reconcile.Request{NamespacedName: types.NamespacedName{Name: tstName, Namespace: tstNamespace}}
 
 				s := scheme.Scheme
-				err := keto1alpha1.AddToScheme(s)
+				err := ketov1alpha2.AddToScheme(s)
 				Expect(err).NotTo(HaveOccurred())
 
 				err = apiv1.AddToScheme(s)
@@ -84,11 +84,11 @@ var _ = Describe("Policy Controller", func() {
 				Eventually(requests, timeout).Should(Receive(Equal(*expectedRequest)))
 
 				//Verify the created CR instance status
-				var retrieved keto1alpha1.Policy
+				var retrieved ketov1alpha2.Policy
 				ok := client.ObjectKey{Name: tstName, Namespace: tstNamespace}
 				err = c.Get(context.TODO(), ok, &retrieved)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(retrieved.Status.ReconciliationError.Description).To(BeEmpty())
+				Expect(ketov1alpha2.IsConditionTrue(retrieved.Status.Conditions, ketov1alpha2.ConditionDegraded)).To(BeFalse())
 
 				//delete instance
 				c.Delete(context.TODO(), instance)
@@ -111,7 +111,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	}
 
 	// Watch for changes to Api
-	err = c.Watch(&source.Kind{Type: &keto1alpha1.Policy{}}, &handler.EnqueueRequestForObject{})
+	err = c.Watch(&source.Kind{Type: &ketov1alpha2.Policy{}}, &handler.EnqueueRequestForObject{})
 	if err != nil {
 		return err
 	}
@@ -129,20 +129,20 @@ func getAPIReconciler(mgr ctrl.Manager, mock controllers.KetoClient) reconcile.R
 	}
 }
 
-func testInstance(name string) *keto1alpha1.Policy {
+func testInstance(name string) *ketov1alpha2.Policy {
 
-	return &keto1alpha1.Policy{
+	return &ketov1alpha2.Policy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: tstNamespace,
 		},
-		Spec: keto1alpha1.PolicySpec{
-			PatternMatching: "exact",
-			Description:     "allow maria to get photos",
-			Subjects:        []string{"users:maria"},
-			Actions:         []string{"list"},
-			Effect:          "allow",
-			Resources:       []string{"resources:photos"},
-			Conditions:      nil,
+		Spec: ketov1alpha2.PolicySpec{
+			Flavour:     "exact",
+			Description: "allow maria to get photos",
+			Subjects:    []string{"users:maria"},
+			Actions:     []string{"list"},
+			Effect:      "allow",
+			Resources:   []string{"resources:photos"},
+			Conditions:  nil,
 		}}
 }
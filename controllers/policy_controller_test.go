@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var policyName = types.NamespacedName{Namespace: "default", Name: "readers"}
+
+func testPolicy() *ketov1alpha2.Policy {
+	return &ketov1alpha2.Policy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: policyName.Namespace, Name: policyName.Name, Generation: 1},
+		Spec: ketov1alpha2.PolicySpec{
+			Subjects:  []string{"alice"},
+			Actions:   []string{"read"},
+			Resources: []string{"books"},
+			Effect:    ketov1alpha2.EffectAllow,
+		},
+	}
+}
+
+func TestSubjectRefs(t *testing.T) {
+
+	syncedRole := func(name string, flavour ketov1alpha2.Flavour) *ketov1alpha2.Role {
+		role := testRole()
+		role.Name = name
+		role.Spec.Flavour = flavour
+		role.Status.Conditions = []ketov1alpha2.Condition{{Type: ketov1alpha2.ConditionSynced, Status: ketov1alpha2.ConditionTrue}}
+		return role
+	}
+
+	for d, tc := range map[string]struct {
+		policyFlavour ketov1alpha2.Flavour
+		role          *ketov1alpha2.Role
+		subjects      []string
+		status        ketov1alpha2.ConditionStatus
+		reason        string
+	}{
+		"same flavour":            {"regex", syncedRole("editors", "regex"), []string{"alice", "default:editors"}, ketov1alpha2.ConditionTrue, ketov1alpha2.ReasonResolved},
+		"both default flavours":   {"", syncedRole("editors", "exact"), []string{"alice", "default:editors"}, ketov1alpha2.ConditionTrue, ketov1alpha2.ReasonResolved},
+		"role of other flavour":   {"regex", syncedRole("editors", "exact"), []string{"alice"}, ketov1alpha2.ConditionFalse, ketov1alpha2.ReasonRoleFlavourMismatch},
+		"policy of other flavour": {"", syncedRole("editors", "glob"), []string{"alice"}, ketov1alpha2.ConditionFalse, ketov1alpha2.ReasonRoleFlavourMismatch},
+		"missing role":            {"regex", syncedRole("others", "regex"), []string{"alice"}, ketov1alpha2.ConditionFalse, ketov1alpha2.ReasonRoleNotFound},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			p := testPolicy()
+			p.Spec.Flavour = tc.policyFlavour
+			p.Spec.SubjectRefs = []ketov1alpha2.RoleRef{{Name: "editors"}}
+			ketoClient := newFakeKetoClient()
+			r, _ := newTestReconciler(ketoClient, p, tc.role)
+
+			//when
+			var reconciled ketov1alpha2.Policy
+			reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+			//then
+			resolved := ketov1alpha2.FindCondition(reconciled.Status.Conditions, ketov1alpha2.ConditionResolved)
+			require.NotNil(t, resolved)
+			assert.Equal(t, tc.status, resolved.Status)
+			assert.Equal(t, tc.reason, resolved.Reason)
+			applied := ketoClient.policies[desiredPolicyLocation(&reconciled)]
+			require.NotNil(t, applied)
+			assert.Equal(t, tc.subjects, applied.Subjects)
+		})
+	}
+}
+
+func TestKetoIDOverride(t *testing.T) {
+
+	t.Run("applies the policy with the ID of its spec", func(t *testing.T) {
+
+		//given
+		p := testPolicy()
+		p.Spec.KetoID = "books:readers"
+		ketoClient := newFakeKetoClient()
+		r, _ := newTestReconciler(ketoClient, p)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Contains(t, ketoClient.policies, ketoLocation{keto.Exact, "books:readers"})
+		assert.NotContains(t, ketoClient.policies, ketoLocation{keto.Exact, "default:readers"})
+		assert.Equal(t, "books:readers", reconciled.Status.KetoID)
+	})
+
+	t.Run("leaves the ID to the policy which claimed it first", func(t *testing.T) {
+
+		//given
+		owner := testPolicy()
+		owner.Name = "owner"
+		owner.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		owner.Spec.KetoID = "books:readers"
+		owner.Spec.Subjects = []string{"bob"}
+		p := testPolicy()
+		p.CreationTimestamp = metav1.Now()
+		p.Spec.KetoID = "books:readers"
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[ketoLocation{keto.Exact, "books:readers"}] = &keto.PolicyJSON{Id: "books:readers", Subjects: []string{"bob"}}
+		r, _ := newTestReconciler(ketoClient, owner, p)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Equal(t, 0, ketoClient.writes)
+		assert.Equal(t, []string{"bob"}, ketoClient.policies[ketoLocation{keto.Exact, "books:readers"}].Subjects)
+		synced := ketov1alpha2.FindCondition(reconciled.Status.Conditions, ketov1alpha2.ConditionSynced)
+		require.NotNil(t, synced)
+		assert.Equal(t, ketov1alpha2.ConditionFalse, synced.Status)
+		assert.Equal(t, ketov1alpha2.ReasonKetoIDConflict, synced.Reason)
+		assert.Contains(t, synced.Message, "default/owner")
+	})
+
+	t.Run("doesn't delete the ID another policy claims", func(t *testing.T) {
+
+		//given
+		other := testPolicy()
+		other.Name = "other"
+		other.Spec.KetoID = "books:readers"
+		p := deletedPolicy(ketov1alpha2.DeletionPolicyDelete)
+		p.Spec.KetoID = "books:readers"
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[ketoLocation{keto.Exact, "books:readers"}] = &keto.PolicyJSON{Id: "books:readers"}
+		r, _ := newTestReconciler(ketoClient, other, p)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Contains(t, ketoClient.policies, ketoLocation{keto.Exact, "books:readers"})
+		assert.NotContains(t, reconciled.Finalizers, FinalizerName)
+	})
+}
+
+func TestPolicyFlavour(t *testing.T) {
+
+	exact := ketoLocation{keto.Exact, "default:readers"}
+	regex := ketoLocation{keto.Regex, "default:readers"}
+
+	t.Run("moves the policy when its flavour changes", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id, Subjects: []string{"alice"}}
+		p := testPolicy()
+		p.Generation = 2
+		p.Finalizers = []string{FinalizerName}
+		p.Spec.Flavour = ketov1alpha2.Flavour(keto.Regex)
+		p.Status.ObservedGeneration = 1
+		p.Status.Flavour = ketov1alpha2.Flavour(keto.Exact)
+		r, recorder := newTestReconciler(ketoClient, p)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.NotContains(t, ketoClient.policies, exact)
+		require.Contains(t, ketoClient.policies, regex)
+		assert.Equal(t, []string{"alice"}, ketoClient.policies[regex].Subjects)
+		assert.Equal(t, ketov1alpha2.Flavour(keto.Regex), reconciled.Status.Flavour)
+		assert.Equal(t, []string{
+			"Normal Created created policy regex/default:readers in ORY Keto",
+			"Normal Deleted deleted policy exact/default:readers from ORY Keto",
+		}, events(recorder))
+	})
+
+	t.Run("keeps the policy where it is if it can't be moved", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id, Subjects: []string{"alice"}}
+		p := testPolicy()
+		p.Generation = 2
+		p.Finalizers = []string{FinalizerName}
+		p.Spec.Flavour = ketov1alpha2.Flavour(keto.Regex)
+		p.Status.ObservedGeneration = 1
+		p.Status.Flavour = ketov1alpha2.Flavour(keto.Exact)
+		r, _ := newTestReconciler(ketoClient, p)
+		ketoClient.err = fmt.Errorf("unavailable")
+
+		//when
+		_, err := (&KetoPolicyReconciler{Reconciler: r}).Reconcile(reconcile.Request{NamespacedName: policyName})
+
+		//then
+		assert.Error(t, err)
+		var reconciled ketov1alpha2.Policy
+		require.NoError(t, r.Get(context.Background(), policyName, &reconciled))
+		assert.Contains(t, ketoClient.policies, exact)
+		assert.Equal(t, ketov1alpha2.Flavour(keto.Exact), reconciled.Status.Flavour)
+	})
+
+	t.Run("deletes both flavours of a policy deleted while moving", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.policies[exact] = &keto.PolicyJSON{Id: exact.id}
+		ketoClient.policies[regex] = &keto.PolicyJSON{Id: regex.id}
+		p := deletedPolicy(ketov1alpha2.DeletionPolicyDelete)
+		p.Spec.Flavour = ketov1alpha2.Flavour(keto.Regex)
+		p.Status.Flavour = ketov1alpha2.Flavour(keto.Exact)
+		r, _ := newTestReconciler(ketoClient, p)
+
+		//when
+		var reconciled ketov1alpha2.Policy
+		reconcileObject(t, &KetoPolicyReconciler{Reconciler: r}, r.Client, policyName, &reconciled)
+
+		//then
+		assert.Empty(t, ketoClient.policies)
+	})
+}
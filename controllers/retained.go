@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ory/keto-maester/keto"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RetainedLocations records the policies and roles kept in ORY Keto by the deletion policy Retain in a
+// ConfigMap, one key per resource listing their flavours and IDs, so that the garbage collector doesn't
+// take them for orphans
+type RetainedLocations struct {
+	// Reader reads the ConfigMap, it should read from the API server so that ConfigMaps aren't cached
+	Reader    client.Reader
+	Writer    client.Writer
+	ConfigMap types.NamespacedName
+}
+
+// Retain records that the resource at locations is kept in ORY Keto
+func (l *RetainedLocations) Retain(ctx context.Context, resource string, locations ...ketoLocation) error {
+	return l.update(ctx, resource, func(retained map[ketoLocation]bool) {
+		for _, location := range locations {
+			retained[location] = true
+		}
+	})
+}
+
+// Release forgets the resource at locations, e.g. because it is deleted from ORY Keto
+func (l *RetainedLocations) Release(ctx context.Context, resource string, locations ...ketoLocation) error {
+	return l.update(ctx, resource, func(retained map[ketoLocation]bool) {
+		for _, location := range locations {
+			delete(retained, location)
+		}
+	})
+}
+
+// List returns the locations of the resource which are kept in ORY Keto
+func (l *RetainedLocations) List(ctx context.Context, resource string) (map[ketoLocation]bool, error) {
+	var configMap corev1.ConfigMap
+	if err := l.Reader.Get(ctx, l.ConfigMap, &configMap); err != nil {
+		if apierrs.IsNotFound(err) {
+			return map[ketoLocation]bool{}, nil
+		}
+		return nil, err
+	}
+	return parseRetained(configMap.Data[resource]), nil
+}
+
+func (l *RetainedLocations) update(ctx context.Context, resource string, change func(retained map[ketoLocation]bool)) error {
+	var configMap corev1.ConfigMap
+	err := l.Reader.Get(ctx, l.ConfigMap, &configMap)
+	if err != nil && !apierrs.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	retained := parseRetained(configMap.Data[resource])
+	before := formatRetained(retained)
+	change(retained)
+	after := formatRetained(retained)
+	if before == after {
+		return nil
+	}
+
+	if !exists {
+		configMap = corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: l.ConfigMap.Namespace, Name: l.ConfigMap.Name}}
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[resource] = after
+
+	// conflicting updates fail, so that the object is requeued and the change is made again
+	if !exists {
+		return l.Writer.Create(ctx, &configMap)
+	}
+	return l.Writer.Update(ctx, &configMap)
+}
+
+// parseRetained parses locations listed one per line as flavour/id
+func parseRetained(data string) map[ketoLocation]bool {
+	retained := map[ketoLocation]bool{}
+	for _, line := range strings.Split(data, "\n") {
+		parts := strings.SplitN(line, "/", 2)
+		if len(parts) == 2 {
+			retained[ketoLocation{keto.Flavour(parts[0]), parts[1]}] = true
+		}
+	}
+	return retained
+}
+
+func formatRetained(retained map[ketoLocation]bool) string {
+	lines := make([]string, 0, len(retained))
+	for location := range retained {
+		lines = append(lines, location.key())
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
@@ -4,14 +4,27 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-logr/logr"
-	ketov1alpha1 "github.com/ory/keto-maester/api/v1alpha1"
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
 	"github.com/ory/keto-maester/keto"
+	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 type KetoRoleReconciler struct {
 	*Reconciler
+
+	// MemberKinds are the kinds besides service accounts and roles members may be resolved from
+	MemberKinds []schema.GroupKind
+	// MemberNamespaces are the namespaces roles of any namespace may resolve members from
+	MemberNamespaces []string
+
+	memberWatches *memberWatches
 }
 
 func (r KetoRoleReconciler) GetLog() logr.Logger {
@@ -23,12 +36,15 @@ func (r KetoRoleReconciler) GetResource() string {
 
 // +kubebuilder:rbac:groups=keto.ory.sh,resources=roles,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=keto.ory.sh,resources=roles/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
 func (r *KetoRoleReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	ctx := context.Background()
+	ctx, cancel := r.reconcileContext()
+	defer cancel()
 	_ = r.Log.WithValues(r.GetResource(), req.NamespacedName)
 
-	var role ketov1alpha1.Role
+	var role ketov1alpha2.Role
 	if err := r.Get(ctx, req.NamespacedName, &role); err != nil {
 		if apierrs.IsNotFound(err) {
 			//if registerErr := r.removeRole(ctx, &role); registerErr != nil {
@@ -39,6 +55,26 @@ func (r *KetoRoleReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
+	// paused objects are neither applied nor removed, their finalizer keeps them until they are resumed
+	paused, err := pausedBy(ctx, r, &role)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if paused != "" {
+		return ctrl.Result{}, updatePausedStatus(ctx, r, &role, role.Status.Conditions, paused)
+	}
+	resumed := resumeReconciliation(&role, role.Status.Conditions)
+
+	if r.KetoHealth != nil {
+		if err := r.KetoHealth.Check(ctx); err != nil {
+			if ctx.Err() != nil {
+				// the reconciliation ran out of time waiting for the probe, which says nothing about ORY Keto
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: ketoUnavailableRequeueAfter}, updateKetoUnavailableStatus(ctx, r, &role, err)
+		}
+	}
+
 	// examine DeletionTimestamp to determine if object is under deletion
 	if role.ObjectMeta.DeletionTimestamp.IsZero() {
 		// The object is not being deleted, so if it does not have our finalizer,
@@ -77,40 +113,258 @@ func (r *KetoRoleReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, registerErr
 	}
 
+	if resumed {
+		// the role was compared with ORY Keto again, which isn't recorded in the status unless it differed
+		return ctrl.Result{}, updateStatus(ctx, r, &role)
+	}
+
 	return ctrl.Result{}, nil
 }
 
-func (r *KetoRoleReconciler) removeRole(ctx context.Context, role *ketov1alpha1.Role) error {
-	id := ketov1alpha1.GenerateId(role)
-	_, exists, err := r.KetoClient.GetRole(keto.Exact, id)
-	if err != nil {
-		return err
+func (r *KetoRoleReconciler) removeRole(ctx context.Context, role *ketov1alpha2.Role) error {
+	// a change of the flavour or ID may have been interrupted before the role was recorded as moved
+	locations := []ketoLocation{appliedRoleLocation(role)}
+	if desired := desiredRoleLocation(role); desired != locations[0] {
+		locations = append(locations, desired)
 	}
-	if exists {
-		return r.KetoClient.DeleteRole(keto.Exact, id)
+
+	if r.retains(role.Spec.DeletionPolicy) {
+		if err := r.retainLocations(ctx, r.GetResource(), locations); err != nil {
+			return err
+		}
+		recordRetained(r, role, locations[0])
+		return nil
 	}
 
-	return nil
+	var removed []ketoLocation
+	for _, location := range locations {
+		kept, err := r.removeRoleFrom(ctx, role, location)
+		if err != nil {
+			return err
+		}
+		if !kept {
+			removed = append(removed, location)
+		}
+	}
+	return r.releaseLocations(ctx, r.GetResource(), removed)
 }
 
-func (r *KetoRoleReconciler) upsertRole(ctx context.Context, role *ketov1alpha1.Role) error {
-	_, exists, _ := r.KetoClient.GetRole(keto.Exact, ketov1alpha1.GenerateId(role))
-	if exists && role.Generation == role.Status.ObservedGeneration {
-		return nil
+// removeRoleFrom removes the role stored at location, unless another role claims it now. Roles merged with
+// members of other systems only lose the members added by the controller, they are kept and recorded as
+// retained so that the garbage collector leaves them to those systems.
+func (r *KetoRoleReconciler) removeRoleFrom(ctx context.Context, role *ketov1alpha2.Role, location ketoLocation) (bool, error) {
+	claimed, err := claimedByOthers(ctx, r, listRoleClaimants, role, location.key())
+	if err != nil || claimed {
+		return false, err
+	}
+
+	flavour, id := location.flavour, location.id
+	current, exists, err := r.KetoClient.GetRole(ctx, flavour, id)
+	if err != nil || !exists {
+		return false, err
 	}
 
-	_, err := r.KetoClient.UpsertRole(keto.Exact, role.ToRoleJSON())
+	if role.Spec.MembershipMode == ketov1alpha2.MembershipMerge {
+		// only take back what we added, the role stays as long as other systems keep members in it
+		for _, member := range role.Status.ManagedMembers {
+			if err := r.KetoClient.RemoveRoleMember(ctx, flavour, id, member); err != nil {
+				return false, err
+			}
+		}
+		if len(subtractStrings(current.Members, role.Status.ManagedMembers)) > 0 {
+			if err := r.retainLocations(ctx, r.GetResource(), []ketoLocation{location}); err != nil {
+				return false, err
+			}
+			recordEvent(r, role, corev1.EventTypeNormal, ReasonDeleted, fmt.Sprintf("removed the members added to role %s in ORY Keto", location.key()))
+			return true, nil
+		}
+	}
 
+	if err := r.KetoClient.DeleteRole(ctx, flavour, id); err != nil {
+		return false, err
+	}
+	recordEvent(r, role, corev1.EventTypeNormal, ReasonDeleted, fmt.Sprintf("deleted role %s from ORY Keto", location.key()))
+	return false, nil
+}
+
+func (r *KetoRoleReconciler) upsertRole(ctx context.Context, role *ketov1alpha2.Role) error {
+	claimants, err := listRoleClaimants(ctx, r, role.KetoKey())
 	if err != nil {
+		return err
+	}
+	if owner := ketoIDConflict(role, claimants); owner != nil {
+		return updateKetoIDConflictStatus(ctx, r, role, owner)
+	}
+
+	resolved, err := r.resolveMembers(ctx, role)
+	if forbidden, ok := err.(*forbiddenMembersError); ok {
+		return updateForbiddenMembersStatus(ctx, r, role, forbidden)
+	}
+	if err != nil {
+		if statusErr := updateReconciliationStatusError(ctx, r, role, err); statusErr != nil {
+			return statusErr
+		}
+		return err
+	}
+	membersChanged := !equalStrings(resolved, role.Status.ResolvedMembers)
+	role.Status.ResolvedMembers = resolved
+
+	desired := desiredRoleLocation(role)
+	current, exists, err := r.KetoClient.GetRole(ctx, desired.flavour, desired.id)
+	if err != nil {
+		return updateKetoStatusError(ctx, r, role, err)
+	}
+
+	// a role which was applied and didn't change since only needs to be written again when it was
+	// changed or deleted in Keto behind our back
+	applied := !membersChanged && desired == appliedRoleLocation(role) && role.Generation == role.Status.ObservedGeneration && ketov1alpha2.IsConditionTrue(role.Status.Conditions, ketov1alpha2.ConditionSynced)
+
+	if exists && role.Spec.MembershipMode == ketov1alpha2.MembershipMerge {
+		return r.mergeRoleMembers(ctx, role, desired, current, applied)
+	}
+
+	drifted := false
+	if applied {
+		if exists && keto.RolesEqual(role.ToRoleJSON(), current) {
+			return nil
+		}
+		recordDrift(r, role, exists)
+		drifted = true
+	}
+
+	if _, err := r.KetoClient.UpsertRole(ctx, desired.flavour, role.ToRoleJSON()); err != nil {
 		r.Log.Error(err, fmt.Sprintf("update failed for %s %s/%s ", r.GetResource(), role.GetName(), role.GetNamespace()), r.GetResource(), "update role")
-		return updateReconciliationStatusError(ctx, r, role, err)
+		return updateKetoStatusError(ctx, r, role, err)
+	}
+	recordApplied(r, role, desired, exists, drifted)
+
+	return r.recordAppliedRole(ctx, role, desired, role.Members())
+}
+
+// mergeRoleMembers adds the members listed in the spec to an existing role and removes the ones it added
+// previously but which are no longer listed, without touching members managed by anybody else.
+// Only the members added by the controller are checked for drift, when the role was applied already.
+func (r *KetoRoleReconciler) mergeRoleMembers(ctx context.Context, role *ketov1alpha2.Role, location ketoLocation, current *keto.Role, applied bool) error {
+	flavour, id := location.flavour, location.id
+	members := role.Members()
+	toAdd := subtractStrings(members, current.Members)
+	toRemove := subtractStrings(role.Status.ManagedMembers, members)
+
+	unchanged := len(toAdd) == 0 && len(toRemove) == 0
+	if applied {
+		if unchanged {
+			return nil
+		}
+		recordDrift(r, role, true)
+	}
+
+	if len(toAdd) > 0 {
+		if _, err := r.KetoClient.AddRoleMembers(ctx, flavour, id, toAdd); err != nil {
+			return updateKetoStatusError(ctx, r, role, err)
+		}
+	}
+
+	for _, member := range toRemove {
+		if !containsString(current.Members, member) {
+			continue
+		}
+		if err := r.KetoClient.RemoveRoleMember(ctx, flavour, id, member); err != nil {
+			return updateKetoStatusError(ctx, r, role, err)
+		}
+	}
+
+	if unchanged {
+		// the members were in the role already, which is only worth an event when the role wasn't synced before
+		if !ketov1alpha2.IsConditionTrue(role.Status.Conditions, ketov1alpha2.ConditionSynced) {
+			recordUnchanged(r, role, location)
+		}
+	} else {
+		recordApplied(r, role, location, true, applied)
+	}
+
+	// members which were in the role already belong to whoever added them, so they are left there when
+	// they are no longer listed or the role is deleted
+	managed := append(subtractStrings(role.Status.ManagedMembers, toRemove), toAdd...)
+	return r.recordAppliedRole(ctx, role, location, managed)
+}
+
+// recordAppliedRole removes the role from where it was applied before, if its flavour or ID changed, and
+// records the members it manages, its flavour and ID it is applied with now. The role is only removed once
+// it exists at its new location, so that it doesn't disappear from Keto in between.
+func (r *KetoRoleReconciler) recordAppliedRole(ctx context.Context, role *ketov1alpha2.Role, location ketoLocation, managed []string) error {
+	if applied := appliedRoleLocation(role); applied != location {
+		if _, err := r.removeRoleFrom(ctx, role, applied); err != nil {
+			return updateKetoStatusError(ctx, r, role, err)
+		}
 	}
 
+	role.Status.ManagedMembers = uniqueSorted(managed)
+	role.Status.Flavour = ketov1alpha2.Flavour(location.flavour)
+	role.Status.KetoID = location.id
 	return ensureEmptyStatusError(ctx, r, role)
 }
 
+// desiredRoleLocation returns where the role is to be applied
+func desiredRoleLocation(role *ketov1alpha2.Role) ketoLocation {
+	flavour := keto.Flavour(ketov1alpha2.DefaultFlavour)
+	if role.Spec.Flavour != "" {
+		flavour = keto.Flavour(role.Spec.Flavour)
+	}
+	return ketoLocation{flavour: flavour, id: role.KetoID()}
+}
+
+// appliedRoleLocation returns where the role was last applied. Roles reconciled before the flavour was
+// recorded can only live in the exact store, while roles never reconciled aren't anywhere yet.
+func appliedRoleLocation(role *ketov1alpha2.Role) ketoLocation {
+	applied := desiredRoleLocation(role)
+	switch {
+	case role.Status.Flavour != "":
+		applied.flavour = keto.Flavour(role.Status.Flavour)
+	case role.Status.ObservedGeneration > 0:
+		applied.flavour = keto.Exact
+	}
+	if role.Status.KetoID != "" {
+		applied.id = role.Status.KetoID
+	}
+	return applied
+}
+
 func (r *KetoRoleReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&ketov1alpha1.Role{}).
-		Complete(r)
+	c, err := controller.New("role", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	for _, h := range []handler.EventHandler{
+		&handler.EnqueueRequestForObject{},
+		enqueueKetoClaimants(mgr.GetClient(), listRoleClaimants),
+		enqueueRolesResolvingFrom(mgr.GetClient(), roleGroupKind),
+	} {
+		if err := c.Watch(&source.Kind{Type: &ketov1alpha2.Role{}}, h); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Watch(&source.Kind{Type: &corev1.Namespace{}}, enqueueNamespaceObjects(mgr.GetClient(), listRoleNames)); err != nil {
+		return err
+	}
+
+	r.memberWatches = &memberWatches{controller: c, client: mgr.GetClient(), watched: map[schema.GroupKind]bool{}}
+	return nil
+}
+
+// listRoleClaimants lists the roles claiming key in ORY Keto
+func listRoleClaimants(ctx context.Context, c client.Reader, key string) ([]ketoClaimant, error) {
+	var list ketov1alpha2.RoleList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	var claimants []ketoClaimant
+	for i := range list.Items {
+		if list.Items[i].KetoKey() == key {
+			claimants = append(claimants, &list.Items[i])
+		}
+	}
+	return claimants, nil
 }
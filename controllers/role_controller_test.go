@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var roleName = types.NamespacedName{Namespace: "default", Name: "readers"}
+
+func testRole(members ...string) *ketov1alpha2.Role {
+	return &ketov1alpha2.Role{
+		ObjectMeta: metav1.ObjectMeta{Namespace: roleName.Namespace, Name: roleName.Name, Generation: 1},
+		Spec:       ketov1alpha2.RoleSpec{Members: members},
+	}
+}
+
+func TestMergeMembers(t *testing.T) {
+
+	exact := ketoLocation{keto.Exact, "default:readers"}
+
+	t.Run("only manages the members it added", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"bob", "carol"}}
+		role := testRole("alice", "bob")
+		role.Spec.MembershipMode = ketov1alpha2.MembershipMerge
+		r, _ := newTestRoleReconciler(ketoClient, role)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.ElementsMatch(t, []string{"bob", "carol", "alice"}, ketoClient.roles[exact].Members)
+		assert.Equal(t, []string{"alice"}, reconciled.Status.ManagedMembers)
+	})
+
+	t.Run("records finding its members in place only once", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"alice", "bob"}}
+		role := testRole("alice")
+		role.Spec.MembershipMode = ketov1alpha2.MembershipMerge
+		r, recorder := newTestRoleReconciler(ketoClient, role)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+		first := events(recorder)
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.Equal(t, 0, ketoClient.writes)
+		assert.Equal(t, []string{"Normal Unchanged role exact/default:readers in ORY Keto is up to date"}, first)
+		assert.Empty(t, events(recorder))
+		assert.True(t, ketov1alpha2.IsConditionTrue(reconciled.Status.Conditions, ketov1alpha2.ConditionSynced))
+	})
+
+	t.Run("removes only managed members no longer listed", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"alice", "bob", "carol"}}
+		role := testRole()
+		role.Spec.MembershipMode = ketov1alpha2.MembershipMerge
+		role.Finalizers = []string{FinalizerName}
+		role.Status.ManagedMembers = []string{"alice"}
+		r, _ := newTestRoleReconciler(ketoClient, role)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.ElementsMatch(t, []string{"bob", "carol"}, ketoClient.roles[exact].Members)
+		assert.Empty(t, reconciled.Status.ManagedMembers)
+	})
+
+	t.Run("keeps managing members it added once listed again", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"alice", "bob"}}
+		role := testRole("alice", "bob", "dave")
+		role.Spec.MembershipMode = ketov1alpha2.MembershipMerge
+		role.Finalizers = []string{FinalizerName}
+		role.Status.ManagedMembers = []string{"alice"}
+		r, _ := newTestRoleReconciler(ketoClient, role)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.ElementsMatch(t, []string{"alice", "bob", "dave"}, ketoClient.roles[exact].Members)
+		assert.Equal(t, []string{"alice", "dave"}, reconciled.Status.ManagedMembers)
+	})
+
+	t.Run("leaves members of others when deleted", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"alice", "bob", "carol"}}
+		role := testRole("alice", "bob")
+		role.Spec.MembershipMode = ketov1alpha2.MembershipMerge
+		role.Finalizers = []string{FinalizerName}
+		now := metav1.Now()
+		role.DeletionTimestamp = &now
+		role.Status.ManagedMembers = []string{"alice"}
+		r, _ := newTestRoleReconciler(ketoClient, role)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		require.Contains(t, ketoClient.roles, exact)
+		assert.ElementsMatch(t, []string{"bob", "carol"}, ketoClient.roles[exact].Members)
+		assert.NotContains(t, reconciled.Finalizers, FinalizerName)
+	})
+
+	t.Run("deletes the role once no other members are left", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"alice"}}
+		role := testRole("alice")
+		role.Spec.MembershipMode = ketov1alpha2.MembershipMerge
+		role.Finalizers = []string{FinalizerName}
+		now := metav1.Now()
+		role.DeletionTimestamp = &now
+		role.Status.ManagedMembers = []string{"alice"}
+		r, _ := newTestRoleReconciler(ketoClient, role)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.NotContains(t, ketoClient.roles, exact)
+	})
+}
+
+func TestRoleFlavour(t *testing.T) {
+
+	t.Run("applies the role in the store of its flavour", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		role := testRole("alice")
+		role.Spec.Flavour = ketov1alpha2.Flavour(keto.Glob)
+		r, _ := newTestRoleReconciler(ketoClient, role)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		glob := ketoLocation{keto.Glob, "default:readers"}
+		require.Contains(t, ketoClient.roles, glob)
+		assert.Equal(t, []string{"alice"}, ketoClient.roles[glob].Members)
+		assert.NotContains(t, ketoClient.roles, ketoLocation{keto.Exact, "default:readers"})
+		assert.Equal(t, ketov1alpha2.Flavour(keto.Glob), reconciled.Status.Flavour)
+	})
+
+	t.Run("moves the role when its flavour changes", func(t *testing.T) {
+
+		//given
+		exact := ketoLocation{keto.Exact, "default:readers"}
+		regex := ketoLocation{keto.Regex, "default:readers"}
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"alice"}}
+		role := testRole("alice")
+		role.Generation = 2
+		role.Finalizers = []string{FinalizerName}
+		role.Spec.Flavour = ketov1alpha2.Flavour(keto.Regex)
+		role.Status.ObservedGeneration = 1
+		role.Status.Flavour = ketov1alpha2.Flavour(keto.Exact)
+		r, recorder := newTestRoleReconciler(ketoClient, role)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.NotContains(t, ketoClient.roles, exact)
+		require.Contains(t, ketoClient.roles, regex)
+		assert.Equal(t, []string{"alice"}, ketoClient.roles[regex].Members)
+		assert.Equal(t, ketov1alpha2.Flavour(keto.Regex), reconciled.Status.Flavour)
+		assert.Equal(t, []string{
+			"Normal Created created role regex/default:readers in ORY Keto",
+			"Normal Deleted deleted role exact/default:readers from ORY Keto",
+		}, events(recorder))
+	})
+
+	t.Run("takes roles reconciled before the flavour was recorded from the exact store", func(t *testing.T) {
+
+		//given
+		exact := ketoLocation{keto.Exact, "default:readers"}
+		ketoClient := newFakeKetoClient()
+		ketoClient.roles[exact] = &keto.Role{Id: exact.id, Members: []string{"alice"}}
+		role := testRole("alice")
+		role.Generation = 2
+		role.Finalizers = []string{FinalizerName}
+		role.Spec.Flavour = ketov1alpha2.Flavour(keto.Glob)
+		role.Status.ObservedGeneration = 1
+		r, _ := newTestRoleReconciler(ketoClient, role)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.NotContains(t, ketoClient.roles, exact)
+		assert.Contains(t, ketoClient.roles, ketoLocation{keto.Glob, "default:readers"})
+	})
+}
+
+func TestMemberResolution(t *testing.T) {
+
+	exact := ketoLocation{keto.Exact, "default:readers"}
+
+	serviceAccount := func(namespace, name string) *corev1.ServiceAccount {
+		// the fake client only reads typed objects as unstructured ones if their kind is set
+		return &corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		}
+	}
+
+	t.Run("resolves referenced service accounts", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		role := testRole("alice")
+		role.Spec.MemberRefs = []ketov1alpha2.MemberRef{{Name: "builder"}, {Name: "missing"}}
+		r, _ := newTestRoleReconciler(ketoClient, role, serviceAccount("default", "builder"))
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.Equal(t, []string{"system:serviceaccount:default:builder"}, reconciled.Status.ResolvedMembers)
+		assert.ElementsMatch(t, []string{"alice", "system:serviceaccount:default:builder"}, ketoClient.roles[exact].Members)
+	})
+
+	t.Run("resolves roles matching the selector", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		editors := testRole("bob")
+		editors.Name = "editors"
+		editors.Labels = map[string]string{"team": "books"}
+		sellers := testRole("carol")
+		sellers.Name = "sellers"
+		sellers.Labels = map[string]string{"team": "sales"}
+		role := testRole("alice")
+		role.Spec.MemberSelector = &ketov1alpha2.MemberSelector{
+			MemberKind:    ketov1alpha2.MemberKind{Kind: "Role", RoleMembership: ketov1alpha2.RoleMembershipNested},
+			LabelSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "books"}},
+		}
+		r, _ := newTestRoleReconciler(ketoClient, role, editors, sellers)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.ElementsMatch(t, []string{"alice", "default:editors"}, ketoClient.roles[exact].Members)
+	})
+
+	t.Run("resolves the members of referenced roles", func(t *testing.T) {
+
+		//given
+		ketoClient := newFakeKetoClient()
+		editors := testRole("bob")
+		editors.Name = "editors"
+		role := testRole("alice")
+		role.Spec.MemberRefs = []ketov1alpha2.MemberRef{{MemberKind: ketov1alpha2.MemberKind{Kind: "Role"}, Name: "editors"}}
+		r, _ := newTestRoleReconciler(ketoClient, role, editors)
+
+		//when
+		var reconciled ketov1alpha2.Role
+		reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+		//then
+		assert.ElementsMatch(t, []string{"alice", "bob"}, ketoClient.roles[exact].Members)
+	})
+
+	for d, tc := range map[string]struct {
+		ref        ketov1alpha2.MemberRef
+		kinds      []schema.GroupKind
+		namespaces []string
+		forbidden  bool
+	}{
+		"kind not allowed":      {ref: ketov1alpha2.MemberRef{MemberKind: ketov1alpha2.MemberKind{APIVersion: "v1", Kind: "Secret"}, Name: "token"}, forbidden: true},
+		"kind allowed":          {ref: ketov1alpha2.MemberRef{MemberKind: ketov1alpha2.MemberKind{APIVersion: "v1", Kind: "Secret"}, Name: "token"}, kinds: []schema.GroupKind{{Kind: "Secret"}}},
+		"namespace not allowed": {ref: ketov1alpha2.MemberRef{Name: "builder", Namespace: "other"}, forbidden: true},
+		"namespace allowed":     {ref: ketov1alpha2.MemberRef{Name: "builder", Namespace: "other"}, namespaces: []string{"other"}},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			ketoClient := newFakeKetoClient()
+			role := testRole("alice")
+			role.Spec.MemberRefs = []ketov1alpha2.MemberRef{tc.ref}
+			r, recorder := newTestRoleReconciler(ketoClient, role)
+			r.MemberKinds = tc.kinds
+			r.MemberNamespaces = tc.namespaces
+
+			//when
+			var reconciled ketov1alpha2.Role
+			reconcileObject(t, r, r.Client, roleName, &reconciled)
+
+			//then
+			synced := ketov1alpha2.FindCondition(reconciled.Status.Conditions, ketov1alpha2.ConditionSynced)
+			require.NotNil(t, synced)
+			if tc.forbidden {
+				assert.Equal(t, ketov1alpha2.ConditionFalse, synced.Status)
+				assert.Equal(t, ketov1alpha2.ReasonMemberRefForbidden, synced.Reason)
+				assert.Equal(t, 0, ketoClient.writes)
+				recorded := events(recorder)
+				require.Len(t, recorded, 1)
+				assert.Contains(t, recorded[0], "Warning MemberRefForbidden")
+			} else {
+				assert.Equal(t, ketov1alpha2.ConditionTrue, synced.Status)
+				assert.Contains(t, ketoClient.roles, exact)
+			}
+		})
+	}
+}
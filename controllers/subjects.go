@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// resolveSubjects resolves the subject references of p to the IDs of the roles in ORY Keto. Missing roles and
+// roles of another flavour, which ORY Keto keeps in another store, are left out, the returned Resolved
+// condition tells whether all references could be resolved.
+func (r *KetoPolicyReconciler) resolveSubjects(ctx context.Context, p *ketov1alpha2.Policy) ([]string, ketov1alpha2.Condition, error) {
+	flavour := desiredPolicyLocation(p).flavour
+	var subjects, missing, mismatched, unsynced []string
+	for _, ref := range p.Spec.SubjectRefs {
+		key := roleRefKey(p, ref)
+
+		var role ketov1alpha2.Role
+		if err := r.Get(ctx, key, &role); err != nil {
+			if apierrs.IsNotFound(err) {
+				missing = append(missing, key.String())
+				continue
+			}
+			return nil, ketov1alpha2.Condition{}, err
+		}
+
+		if roleFlavour := desiredRoleLocation(&role).flavour; roleFlavour != flavour {
+			mismatched = append(mismatched, fmt.Sprintf("%s (%s)", key, roleFlavour))
+			continue
+		}
+
+		subjects = append(subjects, role.KetoID())
+		if !ketov1alpha2.IsConditionTrue(role.Status.Conditions, ketov1alpha2.ConditionSynced) {
+			unsynced = append(unsynced, key.String())
+		}
+	}
+
+	condition := ketov1alpha2.Condition{
+		Type:               ketov1alpha2.ConditionResolved,
+		Status:             ketov1alpha2.ConditionTrue,
+		ObservedGeneration: p.Generation,
+		Reason:             ketov1alpha2.ReasonResolved,
+	}
+	switch {
+	case len(mismatched) > 0:
+		condition.Status = ketov1alpha2.ConditionFalse
+		condition.Reason = ketov1alpha2.ReasonRoleFlavourMismatch
+		condition.Message = fmt.Sprintf("referenced roles aren't of the %s flavour of the policy: %s", flavour, strings.Join(mismatched, ", "))
+	case len(missing) > 0:
+		condition.Status = ketov1alpha2.ConditionFalse
+		condition.Reason = ketov1alpha2.ReasonRoleNotFound
+		condition.Message = fmt.Sprintf("referenced roles don't exist: %s", strings.Join(missing, ", "))
+	case len(unsynced) > 0:
+		condition.Status = ketov1alpha2.ConditionFalse
+		condition.Reason = ketov1alpha2.ReasonRoleNotSynced
+		condition.Message = fmt.Sprintf("referenced roles aren't synced to ORY Keto: %s", strings.Join(unsynced, ", "))
+	}
+
+	return uniqueSorted(subjects), condition, nil
+}
+
+func roleRefKey(p *ketov1alpha2.Policy, ref ketov1alpha2.RoleRef) types.NamespacedName {
+	if ref.Namespace == "" {
+		return types.NamespacedName{Namespace: p.Namespace, Name: ref.Name}
+	}
+	return types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+}
+
+// conditionChanged tells whether setting condition would change more than the transition time of the current one
+func conditionChanged(conditions []ketov1alpha2.Condition, condition ketov1alpha2.Condition) bool {
+	current := ketov1alpha2.FindCondition(conditions, condition.Type)
+	return current == nil || current.Status != condition.Status || current.Reason != condition.Reason || current.Message != condition.Message
+}
+
+// enqueuePoliciesReferencing requeues the policies whose subject references point at the changed role
+func enqueuePoliciesReferencing(c client.Reader) handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+		var policies ketov1alpha2.PolicyList
+		if err := c.List(context.Background(), &policies); err != nil {
+			return nil
+		}
+
+		role := types.NamespacedName{Namespace: o.Meta.GetNamespace(), Name: o.Meta.GetName()}
+		var requests []reconcile.Request
+		for i := range policies.Items {
+			p := &policies.Items[i]
+			for _, ref := range p.Spec.SubjectRefs {
+				if roleRefKey(p, ref) == role {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: p.Namespace, Name: p.Name}})
+					break
+				}
+			}
+		}
+		return requests
+	})}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ory/keto-maester/importer"
+	"github.com/ory/keto-maester/keto"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// stringsFlag collects the values of a flag given several times
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// runImport converts policies and roles of a live ORY Keto or of files in its JSON format into Policy and
+// Role manifests keeping their IDs, and optionally applies them
+func runImport(args []string) error {
+	var (
+		ketoURL, ketoCAFile, ketoCertFile, ketoKeyFile string
+		ketoAuth, ketoAuthDir, flavour                 string
+		namespace, output                              string
+		ketoPort                                       int
+		ketoInsecureSkipVerify, apply, adopt           bool
+		policyFiles, roleFiles, mappings               stringsFlag
+	)
+
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s import [flags]\n\nConverts policies and roles in ORY Keto into Policy and Role manifests keeping their IDs.\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&ketoURL, "keto-url", "", "The address of the ORY Keto admin server to list the policies and roles of all flavours from")
+	flags.IntVar(&ketoPort, "keto-port", 4456, "Port ORY Keto is listening on")
+	flags.StringVar(&ketoCAFile, "keto-ca-file", "", "PEM bundle of the certificate authorities used to verify the ORY Keto admin server")
+	flags.StringVar(&ketoCertFile, "keto-cert-file", "", "PEM client certificate presented to the ORY Keto admin server")
+	flags.StringVar(&ketoKeyFile, "keto-key-file", "", "PEM key of the client certificate presented to the ORY Keto admin server")
+	flags.BoolVar(&ketoInsecureSkipVerify, "keto-insecure-skip-verify", false, "Disables verification of the ORY Keto admin server certificate, only use it for development")
+	flags.StringVar(&ketoAuth, "keto-auth", "none", "Authentication for requests to the ORY Keto admin server, one of none, bearer, basic or headers")
+	flags.StringVar(&ketoAuthDir, "keto-auth-dir", "", "Directory with one file per credential (token, username, password or header names)")
+	flags.Var(&policyFiles, "policies-file", "File with policies in the JSON format of ORY Keto, may be given several times")
+	flags.Var(&roleFiles, "roles-file", "File with roles in the JSON format of ORY Keto, may be given several times")
+	flags.StringVar(&flavour, "flavour", string(keto.Exact), "Pattern matching flavour of the policies and roles read from files, one of exact, regex or glob")
+	flags.Var(&mappings, "namespace-prefix", "Puts the objects of IDs starting with a prefix into a namespace, in the form prefix=namespace, may be given several times")
+	flags.StringVar(&namespace, "namespace", "", "Namespace of the objects whose IDs neither match a prefix nor are shaped like namespace:name, such IDs are skipped if empty")
+	flags.StringVar(&output, "output", "-", "File the manifests are written to, - for stdout")
+	flags.BoolVar(&apply, "apply", false, "Create the objects in the cluster of the current kubeconfig, existing objects are left as they are")
+	flags.BoolVar(&adopt, "adopt", false, "Mark the created objects as applied to ORY Keto, so that the controller only writes them again if they differ, requires apply")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if ketoURL == "" && len(policyFiles) == 0 && len(roleFiles) == 0 {
+		return fmt.Errorf("either keto-url or policies-file or roles-file is required")
+	}
+	if adopt && !apply {
+		return fmt.Errorf("adopt requires apply")
+	}
+	switch keto.Flavour(flavour) {
+	case keto.Exact, keto.Regex, keto.Glob:
+	default:
+		return fmt.Errorf("flavour must be one of exact, regex or glob")
+	}
+
+	opts := importer.Options{Namespace: namespace}
+	for _, value := range mappings {
+		mapping, err := importer.ParseNamespaceMapping(value)
+		if err != nil {
+			return err
+		}
+		opts.Mappings = append(opts.Mappings, mapping)
+	}
+
+	ctx := context.Background()
+	var policies []importer.PolicySource
+	var roles []importer.RoleSource
+
+	if ketoURL != "" {
+		ketoClient, err := newImportKetoClient(ketoURL, ketoPort, ketoAuth, ketoAuthDir, keto.TLSOptions{
+			CAFile:             ketoCAFile,
+			CertFile:           ketoCertFile,
+			KeyFile:            ketoKeyFile,
+			InsecureSkipVerify: ketoInsecureSkipVerify,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, f := range []keto.Flavour{keto.Exact, keto.Regex, keto.Glob} {
+			listedPolicies, err := ketoClient.ListPolicy(ctx, f)
+			if err != nil {
+				return fmt.Errorf("unable to list %s policies: %s", f, err)
+			}
+			for _, p := range listedPolicies {
+				policies = append(policies, importer.PolicySource{Flavour: f, Policy: p})
+			}
+
+			listedRoles, err := ketoClient.ListRole(ctx, f)
+			if err != nil {
+				return fmt.Errorf("unable to list %s roles: %s", f, err)
+			}
+			for _, r := range listedRoles {
+				roles = append(roles, importer.RoleSource{Flavour: f, Role: r})
+			}
+		}
+	}
+
+	for _, file := range policyFiles {
+		var read []*keto.PolicyJSON
+		if err := readKetoFile(file, &read); err != nil {
+			return err
+		}
+		for _, p := range read {
+			policies = append(policies, importer.PolicySource{Flavour: keto.Flavour(flavour), Policy: p})
+		}
+	}
+	for _, file := range roleFiles {
+		var read []*keto.Role
+		if err := readKetoFile(file, &read); err != nil {
+			return err
+		}
+		for _, r := range read {
+			roles = append(roles, importer.RoleSource{Flavour: keto.Flavour(flavour), Role: r})
+		}
+	}
+
+	result, err := importer.Import(policies, roles, opts)
+	if err != nil {
+		return err
+	}
+	for _, skipped := range result.Skipped {
+		fmt.Fprintf(os.Stderr, "skipped %s: no namespace for the ID, map its prefix with namespace-prefix or set namespace\n", skipped)
+	}
+
+	var objs []runtime.Object
+	for _, p := range result.Policies {
+		objs = append(objs, p)
+	}
+	for _, r := range result.Roles {
+		objs = append(objs, r)
+	}
+
+	if err := writeManifests(output, objs); err != nil {
+		return err
+	}
+
+	if apply {
+		return applyImported(ctx, result, adopt)
+	}
+	return nil
+}
+
+// newImportKetoClient creates a client of the ORY Keto admin server for reading its policies and roles
+func newImportKetoClient(ketoURL string, ketoPort int, authType, authDir string, tlsOptions keto.TLSOptions) (*keto.Client, error) {
+	u, err := url.Parse(fmt.Sprintf("%s:%d", ketoURL, ketoPort))
+	if err != nil {
+		return nil, fmt.Errorf("keto URL must be valid url")
+	}
+	tlsOptions.ServerName = u.Hostname()
+
+	transport, err := keto.NewTransport(tlsOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticator, err := newKetoAuthenticator(authType, authDir, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keto.Client{
+		KetoURL:    *u,
+		HTTPClient: &http.Client{Transport: transport},
+		Retry:      keto.RetryPolicy{MaxAttempts: 3, InitialBackoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second},
+		Auth:       authenticator,
+	}, nil
+}
+
+// readKetoFile reads a JSON file holding either a list of objects, as exported by ORY Keto, or a single one
+func readKetoFile(file string, list interface{}) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	trimmed := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(trimmed, "[") {
+		trimmed = "[" + trimmed + "]"
+	}
+	if err := json.Unmarshal([]byte(trimmed), list); err != nil {
+		return fmt.Errorf("unable to read %s: %s", file, err)
+	}
+	return nil
+}
+
+// writeManifests writes objs as YAML documents to output
+func writeManifests(output string, objs []runtime.Object) error {
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	for _, obj := range objs {
+		manifest, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", manifest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyImported creates the imported objects in the cluster of the current kubeconfig, adopting them if requested
+func applyImported(ctx context.Context, result *importer.Result, adopt bool) error {
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	return importer.Apply(ctx, c, result, adopt, os.Stderr)
+}
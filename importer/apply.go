@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/controllers"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// importedObject is an imported policy or role
+type importedObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// Apply creates the imported objects, objects which exist already are left as they are. Adopted objects are
+// created paused, so that the controller doesn't write them to ORY Keto before their status records that
+// they are applied there already, and resumed once it does. Progress is reported to log.
+func Apply(ctx context.Context, c client.Client, result *Result, adopt bool, log io.Writer) error {
+	for _, p := range result.Policies {
+		if err := apply(ctx, c, p, adopt, log); err != nil {
+			return err
+		}
+	}
+	for _, r := range result.Roles {
+		if err := apply(ctx, c, r, adopt, log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func apply(ctx context.Context, c client.Client, obj importedObject, adopt bool, log io.Writer) error {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	if adopt {
+		setPaused(obj, true)
+	}
+	if err := c.Create(ctx, obj); err != nil {
+		if apierrs.IsAlreadyExists(err) {
+			fmt.Fprintf(log, "%s %s exists already, left as it is\n", kind, key)
+			return nil
+		}
+		return fmt.Errorf("unable to create %s %s: %s", kind, key, err)
+	}
+	fmt.Fprintf(log, "created %s %s\n", kind, key)
+	if !adopt {
+		return nil
+	}
+
+	// the controller records that the object is paused meanwhile, so it is read again on conflicts
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := newImportedObject(obj)
+		if err := c.Get(ctx, key, current); err != nil {
+			return err
+		}
+		adoptObject(current)
+		return c.Status().Update(ctx, current)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to adopt %s %s, it stays paused: %s", kind, key, err)
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := newImportedObject(obj)
+		if err := c.Get(ctx, key, current); err != nil {
+			return err
+		}
+		setPaused(current, false)
+		return c.Update(ctx, current)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to resume adopted %s %s, remove its annotation %s: %s", kind, key, controllers.PausedAnnotation, err)
+	}
+	return nil
+}
+
+// setPaused adds or removes the annotation pausing the reconciliation of obj
+func setPaused(obj importedObject, paused bool) {
+	annotations := map[string]string{}
+	for k, v := range obj.GetAnnotations() {
+		annotations[k] = v
+	}
+	if paused {
+		annotations[controllers.PausedAnnotation] = "true"
+	} else {
+		delete(annotations, controllers.PausedAnnotation)
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+}
+
+func newImportedObject(obj importedObject) importedObject {
+	if _, ok := obj.(*ketov1alpha2.Role); ok {
+		return &ketov1alpha2.Role{}
+	}
+	return &ketov1alpha2.Policy{}
+}
+
+func adoptObject(obj importedObject) {
+	switch o := obj.(type) {
+	case *ketov1alpha2.Policy:
+		AdoptPolicy(o, o.Generation)
+	case *ketov1alpha2.Role:
+		AdoptRole(o, o.Generation)
+	}
+}
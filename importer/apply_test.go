@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/controllers"
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func init() {
+	// the fake client decodes with the client-go scheme
+	if err := ketov1alpha2.AddToScheme(scheme.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+// racingClient behaves like the controller reconciling objects as soon as they are created: it adds its
+// finalizer and fails the next conflicts updates as they are based on an outdated version
+type racingClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *racingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOptionFunc) error {
+	if err := c.Client.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+	accessor := obj.(importedObject)
+	reconciled := newImportedObject(accessor)
+	if err := c.Client.Get(ctx, types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}, reconciled); err != nil {
+		return err
+	}
+	reconciled.SetFinalizers([]string{controllers.FinalizerName})
+	return c.Client.Update(ctx, reconciled)
+}
+
+func (c *racingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOptionFunc) error {
+	if c.conflicts > 0 {
+		c.conflicts--
+		accessor := obj.(importedObject)
+		return apierrs.NewConflict(schema.GroupResource{Group: ketov1alpha2.GroupVersion.Group}, accessor.GetName(), nil)
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *racingClient) Status() client.StatusWriter {
+	// the fake status writer updates the whole object, which is all this client needs to intercept
+	return c
+}
+
+func importedResult(t *testing.T) *Result {
+	result, err := Import(
+		[]PolicySource{{Flavour: keto.Regex, Policy: &keto.PolicyJSON{Id: "payments:read", Subjects: []string{"alice"}, Actions: []string{"read"}, Resources: []string{"payments"}, Effect: "allow"}}},
+		[]RoleSource{{Flavour: keto.Exact, Role: &keto.Role{Id: "payments:readers", Members: []string{"alice"}}}},
+		Options{},
+	)
+	require.NoError(t, err)
+	return result
+}
+
+func TestApply(t *testing.T) {
+
+	policyKey := types.NamespacedName{Namespace: "payments", Name: "read"}
+	roleKey := types.NamespacedName{Namespace: "payments", Name: "readers"}
+
+	t.Run("creates the objects without adopting them", func(t *testing.T) {
+
+		//given
+		c := fake.NewFakeClientWithScheme(scheme.Scheme)
+
+		//when
+		err := Apply(context.Background(), c, importedResult(t), false, &bytes.Buffer{})
+
+		//then
+		require.NoError(t, err)
+		var policy ketov1alpha2.Policy
+		require.NoError(t, c.Get(context.Background(), policyKey, &policy))
+		assert.Empty(t, policy.Annotations)
+		assert.Empty(t, policy.Status.Conditions)
+	})
+
+	t.Run("adopts and resumes the objects while the controller reconciles them", func(t *testing.T) {
+
+		//given
+		c := &racingClient{Client: fake.NewFakeClientWithScheme(scheme.Scheme), conflicts: 2}
+
+		//when
+		err := Apply(context.Background(), c, importedResult(t), true, &bytes.Buffer{})
+
+		//then
+		require.NoError(t, err)
+		var policy ketov1alpha2.Policy
+		require.NoError(t, c.Get(context.Background(), policyKey, &policy))
+		assert.NotContains(t, policy.Annotations, controllers.PausedAnnotation)
+		assert.Equal(t, []string{controllers.FinalizerName}, policy.Finalizers)
+		assert.Equal(t, "payments:read", policy.Status.KetoID)
+		assert.Equal(t, ketov1alpha2.Flavour(keto.Regex), policy.Status.Flavour)
+		synced := ketov1alpha2.FindCondition(policy.Status.Conditions, ketov1alpha2.ConditionSynced)
+		require.NotNil(t, synced)
+		assert.Equal(t, ketov1alpha2.ReasonAdopted, synced.Reason)
+
+		var role ketov1alpha2.Role
+		require.NoError(t, c.Get(context.Background(), roleKey, &role))
+		assert.NotContains(t, role.Annotations, controllers.PausedAnnotation)
+		assert.Equal(t, []string{"alice"}, role.Status.ManagedMembers)
+	})
+
+	t.Run("leaves objects paused which couldn't be adopted", func(t *testing.T) {
+
+		//given
+		c := &racingClient{Client: fake.NewFakeClientWithScheme(scheme.Scheme), conflicts: 100}
+
+		//when
+		err := Apply(context.Background(), c, importedResult(t), true, &bytes.Buffer{})
+
+		//then
+		assert.Error(t, err)
+		var policy ketov1alpha2.Policy
+		require.NoError(t, c.Get(context.Background(), policyKey, &policy))
+		assert.Equal(t, "true", policy.Annotations[controllers.PausedAnnotation])
+	})
+
+	t.Run("leaves existing objects as they are", func(t *testing.T) {
+
+		//given
+		existing := &ketov1alpha2.Policy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: policyKey.Namespace, Name: policyKey.Name},
+			Spec:       ketov1alpha2.PolicySpec{Subjects: []string{"bob"}},
+		}
+		c := fake.NewFakeClientWithScheme(scheme.Scheme, existing)
+		log := &bytes.Buffer{}
+
+		//when
+		err := Apply(context.Background(), c, importedResult(t), true, log)
+
+		//then
+		require.NoError(t, err)
+		var policy ketov1alpha2.Policy
+		require.NoError(t, c.Get(context.Background(), policyKey, &policy))
+		assert.Equal(t, []string{"bob"}, policy.Spec.Subjects)
+		assert.Empty(t, policy.Annotations)
+		assert.Empty(t, policy.Status.Conditions)
+		assert.Contains(t, log.String(), "Policy payments/read exists already")
+	})
+}
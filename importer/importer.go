@@ -0,0 +1,238 @@
+// Package importer converts policies and roles stored in ORY Keto into Policy and Role objects, so that
+// existing state can be adopted by the controller.
+package importer
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NamespaceMapping puts the objects of IDs starting with Prefix into Namespace, named after the rest of the ID
+type NamespaceMapping struct {
+	Prefix    string
+	Namespace string
+}
+
+// ParseNamespaceMapping parses a mapping in the form prefix=namespace
+func ParseNamespaceMapping(value string) (NamespaceMapping, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return NamespaceMapping{}, fmt.Errorf("namespace mapping %q must be in the form prefix=namespace", value)
+	}
+	if errs := validation.IsDNS1123Label(parts[1]); len(errs) > 0 {
+		return NamespaceMapping{}, fmt.Errorf("namespace mapping %q: %s", value, strings.Join(errs, ", "))
+	}
+	return NamespaceMapping{Prefix: parts[0], Namespace: parts[1]}, nil
+}
+
+// Options control where imported objects are put
+type Options struct {
+	// Mappings of ID prefixes to namespaces, the longest matching prefix wins
+	Mappings []NamespaceMapping
+	// Namespace of the objects whose IDs neither match a mapping nor are shaped like namespace:name.
+	// If empty, such IDs are skipped.
+	Namespace string
+}
+
+// Result are the imported objects and the IDs which couldn't be imported
+type Result struct {
+	Policies []*ketov1alpha2.Policy
+	Roles    []*ketov1alpha2.Role
+	Skipped  []string
+}
+
+// PolicySource is a policy as stored in ORY Keto
+type PolicySource struct {
+	Flavour keto.Flavour
+	Policy  *keto.PolicyJSON
+}
+
+// RoleSource is a role as stored in ORY Keto
+type RoleSource struct {
+	Flavour keto.Flavour
+	Role    *keto.Role
+}
+
+// Import converts policies and roles into objects which apply them with the same IDs and flavours.
+// Objects are named after the IDs, IDs which aren't valid names are made unique within their namespace.
+func Import(policies []PolicySource, roles []RoleSource, opts Options) (*Result, error) {
+	result := &Result{}
+
+	sort.SliceStable(policies, func(i, j int) bool {
+		return sourceLess(policies[i].Flavour, policies[i].Policy.Id, policies[j].Flavour, policies[j].Policy.Id)
+	})
+	names := newNamer()
+	for _, source := range policies {
+		meta, ok := opts.objectMeta(names, source.Policy.Id)
+		if !ok {
+			result.Skipped = append(result.Skipped, ketov1alpha2.KetoKey(ketov1alpha2.Flavour(source.Flavour), source.Policy.Id))
+			continue
+		}
+		policy, err := convertPolicy(meta, source)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %s", source.Policy.Id, err)
+		}
+		result.Policies = append(result.Policies, policy)
+	}
+
+	sort.SliceStable(roles, func(i, j int) bool {
+		return sourceLess(roles[i].Flavour, roles[i].Role.Id, roles[j].Flavour, roles[j].Role.Id)
+	})
+	names = newNamer()
+	for _, source := range roles {
+		meta, ok := opts.objectMeta(names, source.Role.Id)
+		if !ok {
+			result.Skipped = append(result.Skipped, ketov1alpha2.KetoKey(ketov1alpha2.Flavour(source.Flavour), source.Role.Id))
+			continue
+		}
+		result.Roles = append(result.Roles, convertRole(meta, source))
+	}
+
+	return result, nil
+}
+
+// sourceLess orders by ID first, so that an ID gets the same name whichever flavours it exists in
+func sourceLess(fi keto.Flavour, idi string, fj keto.Flavour, idj string) bool {
+	if idi != idj {
+		return idi < idj
+	}
+	return fi < fj
+}
+
+func convertPolicy(meta metav1.ObjectMeta, source PolicySource) (*ketov1alpha2.Policy, error) {
+	conditions, err := ketov1alpha2.PolicyConditionsFromKeto(source.Policy.Conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &ketov1alpha2.Policy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha2.GroupVersion.String(), Kind: "Policy"},
+		ObjectMeta: meta,
+		Spec: ketov1alpha2.PolicySpec{
+			Flavour:     ketov1alpha2.Flavour(source.Flavour),
+			Description: source.Policy.Description,
+			Subjects:    source.Policy.Subjects,
+			Actions:     source.Policy.Actions,
+			Effect:      ketov1alpha2.Effect(source.Policy.Effect),
+			Resources:   source.Policy.Resources,
+			Conditions:  conditions,
+		},
+	}
+	if id := source.Policy.Id; id != ketov1alpha2.GenerateId(policy) {
+		policy.Spec.KetoID = id
+	}
+	return policy, nil
+}
+
+func convertRole(meta metav1.ObjectMeta, source RoleSource) *ketov1alpha2.Role {
+	role := &ketov1alpha2.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha2.GroupVersion.String(), Kind: "Role"},
+		ObjectMeta: meta,
+		Spec: ketov1alpha2.RoleSpec{
+			Flavour: ketov1alpha2.Flavour(source.Flavour),
+			Members: source.Role.Members,
+		},
+	}
+	if id := source.Role.Id; id != ketov1alpha2.GenerateId(role) {
+		role.Spec.KetoID = id
+	}
+	return role
+}
+
+// objectMeta returns the namespace and name of the object of id, or false if id has no namespace
+func (o Options) objectMeta(names *namer, id string) (metav1.ObjectMeta, bool) {
+	var mapping *NamespaceMapping
+	for i := range o.Mappings {
+		if strings.HasPrefix(id, o.Mappings[i].Prefix) && (mapping == nil || len(o.Mappings[i].Prefix) > len(mapping.Prefix)) {
+			mapping = &o.Mappings[i]
+		}
+	}
+
+	if mapping != nil {
+		return names.name(mapping.Namespace, strings.TrimPrefix(id, mapping.Prefix), id), true
+	}
+	if namespace, name, ok := ketov1alpha2.ParseGeneratedId(id); ok {
+		return names.name(namespace, name, id), true
+	}
+	if o.Namespace != "" {
+		return names.name(o.Namespace, id, id), true
+	}
+	return metav1.ObjectMeta{}, false
+}
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// namer hands out distinct names per namespace
+type namer struct {
+	taken map[string]bool
+}
+
+func newNamer() *namer {
+	return &namer{taken: map[string]bool{}}
+}
+
+// name derives a valid name from the wanted one, adding a hash of id if it had to be changed or is taken
+func (n *namer) name(namespace, wanted, id string) metav1.ObjectMeta {
+	name := invalidNameCharacters.ReplaceAllString(strings.ToLower(wanted), "-")
+	name = strings.Trim(name, "-.")
+
+	if name != wanted || len(name) > validation.DNS1123SubdomainMaxLength || n.taken[namespace+"/"+name] {
+		suffix := fmt.Sprintf("%x", sha256.Sum256([]byte(id)))[:8]
+		if max := validation.DNS1123SubdomainMaxLength - len(suffix) - 1; len(name) > max {
+			name = strings.Trim(name[:max], "-.")
+		}
+		if name == "" {
+			name = suffix
+		} else {
+			name = name + "-" + suffix
+		}
+	}
+
+	n.taken[namespace+"/"+name] = true
+	return metav1.ObjectMeta{Namespace: namespace, Name: name}
+}
+
+// AdoptPolicy records in the status of policy that it is applied to ORY Keto as it is, so that the controller
+// doesn't write it again unless it differs. generation is the one of the created object.
+func AdoptPolicy(policy *ketov1alpha2.Policy, generation int64) {
+	policy.Status.ObservedGeneration = generation
+	policy.Status.KetoID = policy.KetoID()
+	policy.Status.Flavour = policy.Spec.Flavour
+	setAdoptedConditions(&policy.Status.Conditions, generation)
+}
+
+// AdoptRole records in the status of role that it is applied to ORY Keto as it is, see AdoptPolicy
+func AdoptRole(role *ketov1alpha2.Role, generation int64) {
+	role.Status.ObservedGeneration = generation
+	role.Status.KetoID = role.KetoID()
+	role.Status.Flavour = role.Spec.Flavour
+	role.Status.ManagedMembers = role.Members()
+	setAdoptedConditions(&role.Status.Conditions, generation)
+}
+
+func setAdoptedConditions(conditions *[]ketov1alpha2.Condition, generation int64) {
+	for _, c := range []struct {
+		conditionType ketov1alpha2.ConditionType
+		status        ketov1alpha2.ConditionStatus
+	}{
+		{ketov1alpha2.ConditionReady, ketov1alpha2.ConditionTrue},
+		{ketov1alpha2.ConditionSynced, ketov1alpha2.ConditionTrue},
+		{ketov1alpha2.ConditionDegraded, ketov1alpha2.ConditionFalse},
+	} {
+		ketov1alpha2.SetCondition(conditions, ketov1alpha2.Condition{
+			Type:               c.conditionType,
+			Status:             c.status,
+			ObservedGeneration: generation,
+			Reason:             ketov1alpha2.ReasonAdopted,
+			Message:            "adopted from ORY Keto",
+		})
+	}
+}
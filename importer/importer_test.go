@@ -0,0 +1,155 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportPolicy(t *testing.T) {
+
+	//given
+	policies := []PolicySource{{Flavour: keto.Regex, Policy: &keto.PolicyJSON{
+		Id:          "payments:read",
+		Description: "reading payments",
+		Subjects:    []string{"users:<.*>"},
+		Actions:     []string{"read"},
+		Resources:   []string{"payments"},
+		Effect:      "allow",
+		Conditions:  json.RawMessage(`{"remoteIP":{"type":"CIDRCondition","options":{"cidr":"10.0.0.0/8"}}}`),
+	}}}
+
+	//when
+	result, err := Import(policies, nil, Options{})
+
+	//then
+	require.NoError(t, err)
+	require.Len(t, result.Policies, 1)
+	policy := result.Policies[0]
+	assert.Equal(t, "Policy", policy.Kind)
+	assert.Equal(t, "payments", policy.Namespace)
+	assert.Equal(t, "read", policy.Name)
+	assert.Empty(t, policy.Spec.KetoID)
+	assert.Equal(t, "payments:read", policy.KetoID())
+	assert.Equal(t, ketov1alpha2.Flavour(keto.Regex), policy.Spec.Flavour)
+	assert.Equal(t, ketov1alpha2.EffectAllow, policy.Spec.Effect)
+	assert.Contains(t, policy.Spec.Conditions, "remoteIP")
+
+	converted, err := policy.ToPolicyJSON()
+	require.NoError(t, err)
+	assert.True(t, keto.PoliciesEqual(policies[0].Policy, converted))
+}
+
+func TestImportNames(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		id        string
+		opts      Options
+		namespace string
+		name      string
+		ketoID    string
+	}{
+		"generated id":        {id: "payments:read", namespace: "payments", name: "read"},
+		"mapped prefix":       {id: "policies:payments:read", opts: Options{Mappings: []NamespaceMapping{{"policies:payments:", "payments"}}}, namespace: "payments", name: "read", ketoID: "policies:payments:read"},
+		"longest prefix wins": {id: "policies:payments:read", opts: Options{Mappings: []NamespaceMapping{{"policies:", "default"}, {"policies:payments:", "payments"}}}, namespace: "payments", name: "read", ketoID: "policies:payments:read"},
+		"sanitized name":      {id: "Policies:Payments:Read", opts: Options{Namespace: "default"}, namespace: "default", name: "policies-payments-read-3f3abc27", ketoID: "Policies:Payments:Read"},
+		"default namespace":   {id: "admin", opts: Options{Namespace: "default"}, namespace: "default", name: "admin", ketoID: "admin"},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//when
+			result, err := Import([]PolicySource{{Flavour: keto.Exact, Policy: &keto.PolicyJSON{Id: tc.id}}}, nil, tc.opts)
+
+			//then
+			require.NoError(t, err)
+			require.Len(t, result.Policies, 1)
+			assert.Equal(t, tc.namespace, result.Policies[0].Namespace)
+			assert.Equal(t, tc.name, result.Policies[0].Name)
+			assert.Equal(t, tc.ketoID, result.Policies[0].Spec.KetoID)
+			assert.Equal(t, tc.id, result.Policies[0].KetoID())
+		})
+	}
+}
+
+func TestImportSkipsIDsWithoutNamespace(t *testing.T) {
+
+	//when
+	result, err := Import(nil, []RoleSource{{Flavour: keto.Exact, Role: &keto.Role{Id: "admins"}}}, Options{})
+
+	//then
+	require.NoError(t, err)
+	assert.Empty(t, result.Roles)
+	assert.Equal(t, []string{"exact/admins"}, result.Skipped)
+}
+
+func TestImportDistinctNames(t *testing.T) {
+
+	//given
+	roles := []RoleSource{
+		{Flavour: keto.Regex, Role: &keto.Role{Id: "payments:admins"}},
+		{Flavour: keto.Exact, Role: &keto.Role{Id: "payments:admins"}},
+	}
+
+	//when
+	result, err := Import(nil, roles, Options{})
+
+	//then
+	require.NoError(t, err)
+	require.Len(t, result.Roles, 2)
+	assert.Equal(t, "admins", result.Roles[0].Name)
+	assert.Equal(t, ketov1alpha2.Flavour(keto.Exact), result.Roles[0].Spec.Flavour)
+	assert.NotEqual(t, "admins", result.Roles[1].Name)
+	assert.Equal(t, "payments:admins", result.Roles[1].KetoID())
+}
+
+func TestParseNamespaceMapping(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		value   string
+		mapping NamespaceMapping
+		valid   bool
+	}{
+		"valid":             {value: "policies:payments:=payments", mapping: NamespaceMapping{"policies:payments:", "payments"}, valid: true},
+		"missing namespace": {value: "policies:="},
+		"missing prefix":    {value: "=payments"},
+		"invalid namespace": {value: "policies:=Payments"},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//when
+			mapping, err := ParseNamespaceMapping(tc.value)
+
+			//then
+			if !tc.valid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.mapping, mapping)
+		})
+	}
+}
+
+func TestAdoptRole(t *testing.T) {
+
+	//given
+	result, err := Import(nil, []RoleSource{{Flavour: keto.Glob, Role: &keto.Role{Id: "payments:admins", Members: []string{"alice"}}}}, Options{})
+	require.NoError(t, err)
+	role := result.Roles[0]
+
+	//when
+	AdoptRole(role, 1)
+
+	//then
+	assert.Equal(t, int64(1), role.Status.ObservedGeneration)
+	assert.Equal(t, "payments:admins", role.Status.KetoID)
+	assert.Equal(t, ketov1alpha2.Flavour(keto.Glob), role.Status.Flavour)
+	assert.Equal(t, []string{"alice"}, role.Status.ManagedMembers)
+	assert.True(t, ketov1alpha2.IsConditionTrue(role.Status.Conditions, ketov1alpha2.ConditionSynced))
+	assert.Equal(t, ketov1alpha2.ReasonAdopted, ketov1alpha2.FindCondition(role.Status.Conditions, ketov1alpha2.ConditionReady).Reason)
+}
@@ -0,0 +1,132 @@
+package keto
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Keys looked up in the credentials of the built-in authenticators
+const (
+	CredentialToken    = "token"
+	CredentialUsername = "username"
+	CredentialPassword = "password"
+)
+
+// Authenticator adds credentials to every request sent to the ORY Keto admin server
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// CredentialsSource provides the current credentials by key. Sources are asked on every request,
+// so rotated credentials are used without restarting the process.
+type CredentialsSource interface {
+	Credentials(ctx context.Context) (map[string]string, error)
+}
+
+// StaticCredentials are credentials that never change
+type StaticCredentials map[string]string
+
+func (s StaticCredentials) Credentials(context.Context) (map[string]string, error) {
+	return s, nil
+}
+
+// FileCredentials reads credentials from a directory with one file per key,
+// the layout of a Kubernetes Secret mounted as a volume
+type FileCredentials struct {
+	Dir string
+}
+
+func (f *FileCredentials) Credentials(context.Context) (map[string]string, error) {
+	files, err := ioutil.ReadDir(f.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := map[string]string{}
+	for _, file := range files {
+		// skips the ..data and timestamped directories kubelet uses to swap mounted secrets atomically
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		// secret volumes consist of symlinks, so the mode of the entry says nothing about its target
+		value, err := ioutil.ReadFile(filepath.Join(f.Dir, file.Name()))
+		if err != nil {
+			if file.IsDir() {
+				continue
+			}
+			return nil, err
+		}
+		credentials[file.Name()] = strings.TrimSpace(string(value))
+	}
+	return credentials, nil
+}
+
+// BearerTokenAuth sends the "token" credential as a bearer token
+type BearerTokenAuth struct {
+	Source CredentialsSource
+}
+
+func (a *BearerTokenAuth) Authenticate(req *http.Request) error {
+	credentials, err := a.Source.Credentials(req.Context())
+	if err != nil {
+		return err
+	}
+
+	token, err := requireCredential(credentials, CredentialToken)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// BasicAuth sends the "username" and "password" credentials using HTTP basic authentication
+type BasicAuth struct {
+	Source CredentialsSource
+}
+
+func (a *BasicAuth) Authenticate(req *http.Request) error {
+	credentials, err := a.Source.Credentials(req.Context())
+	if err != nil {
+		return err
+	}
+
+	username, err := requireCredential(credentials, CredentialUsername)
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(username, credentials[CredentialPassword])
+	return nil
+}
+
+// HeaderAuth sends every credential as a header named after its key
+type HeaderAuth struct {
+	Source CredentialsSource
+}
+
+func (a *HeaderAuth) Authenticate(req *http.Request) error {
+	credentials, err := a.Source.Credentials(req.Context())
+	if err != nil {
+		return err
+	}
+
+	for name, value := range credentials {
+		req.Header.Set(name, value)
+	}
+	return nil
+}
+
+func requireCredential(credentials map[string]string, key string) (string, error) {
+	value := credentials[key]
+	if value == "" {
+		return "", fmt.Errorf("credential %q is missing", key)
+	}
+	return value, nil
+}
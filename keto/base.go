@@ -2,6 +2,7 @@ package keto
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -14,9 +15,11 @@ type Client struct {
 	KetoURL        url.URL
 	HTTPClient     *http.Client
 	ForwardedProto string
+	Retry          RetryPolicy
+	Auth           Authenticator
 }
 
-func (c *Client) newRequest(method, relativePath string, body interface{}) (*http.Request, error) {
+func (c *Client) newRequest(ctx context.Context, method, relativePath string, body interface{}) (*http.Request, error) {
 	var buf io.ReadWriter
 	if body != nil {
 		buf = new(bytes.Buffer)
@@ -26,10 +29,16 @@ func (c *Client) newRequest(method, relativePath string, body interface{}) (*htt
 		}
 	}
 
+	// the relative path is escaped already, so that ids and members containing slashes stay a single segment
 	u := c.KetoURL
-	u.Path = path.Join(u.Path, relativePath)
+	escaped := path.Join(u.EscapedPath(), relativePath)
+	unescaped, err := url.PathUnescape(escaped)
+	if err != nil {
+		return nil, err
+	}
+	u.Path, u.RawPath = unescaped, escaped
 
-	req, err := http.NewRequest(method, u.String(), buf)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), buf)
 	if err != nil {
 		return nil, err
 	}
@@ -46,18 +55,46 @@ func (c *Client) newRequest(method, relativePath string, body interface{}) (*htt
 		req.Header.Set("Origin", os.Getenv("ORIGIN"))
 	}
 
+	if c.Auth != nil {
+		if err := c.Auth.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+
 	return req, nil
 
 }
 
 func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.doOnce(req, v)
+		if err == nil || !c.Retry.shouldRetry(req, attempt, err) {
+			return resp, err
+		}
+
+		if waitErr := c.Retry.wait(req.Context(), attempt); waitErr != nil {
+			return resp, err
+		}
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (c *Client) doOnce(req *http.Request, v interface{}) (*http.Response, error) {
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	defer resp.Body.Close()
-	if v != nil && resp.StatusCode < 300 {
+	if resp.StatusCode >= 300 {
+		return resp, newAPIError(req, resp)
+	}
+	if v != nil && resp.StatusCode != http.StatusNoContent {
 		err = json.NewDecoder(resp.Body).Decode(v)
 	}
 	return resp, err
@@ -0,0 +1,37 @@
+package keto
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+func (c *Client) AcpEngineAllowedPath(flavour Flavour) string {
+	return fmt.Sprintf("/engines/acp/ory/%s/allowed", flavour)
+}
+
+// IsAllowed asks ORY Keto whether subject may perform action on resource using the policies of the given flavour.
+// A denied request is a valid decision and is not reported as an error.
+func (c *Client) IsAllowed(ctx context.Context, flavour Flavour, subject, action, resource string, requestContext map[string]interface{}) (*AuthorizationResult, error) {
+	var result *AuthorizationResult
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.AcpEngineAllowedPath(flavour), &AllowedInput{
+		Subject:  subject,
+		Action:   action,
+		Resource: resource,
+		Context:  requestContext,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.do(req, &result)
+	switch {
+	case hasStatusCode(err, http.StatusForbidden):
+		return &AuthorizationResult{Allowed: false}, nil
+	case err != nil:
+		return nil, err
+	default:
+		return result, nil
+	}
+}
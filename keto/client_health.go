@@ -0,0 +1,32 @@
+package keto
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	healthAlivePath = "/health/alive"
+	healthReadyPath = "/health/ready"
+)
+
+// Health returns an error unless ORY Keto reports that it is alive
+func (c *Client) Health(ctx context.Context) error {
+	return c.checkHealth(ctx, healthAlivePath)
+}
+
+// Ready returns an error unless ORY Keto reports that it is ready to serve requests, including its database
+func (c *Client) Ready(ctx context.Context) error {
+	return c.checkHealth(ctx, healthReadyPath)
+}
+
+func (c *Client) checkHealth(ctx context.Context, path string) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+
+	// health checks report the current state, retrying would only delay the answer
+	_, err = c.doOnce(req, nil)
+	return err
+}
@@ -1,99 +1,104 @@
 package keto
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
 func (c *Client) AcpEnginePolicyPath(flavour Flavour, id string) string {
-	return fmt.Sprintf("/engines/acp/ory/%s/policies/%s", flavour, id)
+	return fmt.Sprintf("/engines/acp/ory/%s/policies/%s", flavour, url.PathEscape(id))
 }
 
-func (c *Client) GetPolicy(flavour Flavour, id string) (*PolicyJSON, bool, error) {
+func (c *Client) GetPolicy(ctx context.Context, flavour Flavour, id string) (*PolicyJSON, bool, error) {
 	var jsonClient *PolicyJSON
 
-	req, err := c.newRequest(http.MethodGet, c.AcpEnginePolicyPath(flavour, id), nil)
+	req, err := c.newRequest(ctx, http.MethodGet, c.AcpEnginePolicyPath(flavour, id), nil)
 	if err != nil {
 		return nil, false, err
 	}
 
-	resp, err := c.do(req, &jsonClient)
-	if err != nil {
-		return nil, false, err
-	}
-
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return jsonClient, true, nil
-	case http.StatusNotFound:
+	_, err = c.do(req, &jsonClient)
+	switch {
+	case IsNotFound(err):
 		return nil, false, nil
+	case err != nil:
+		return nil, false, err
 	default:
-		return nil, false, fmt.Errorf("%s %s http request returned unexpected status code %s", req.Method, req.URL.String(), resp.Status)
+		return jsonClient, true, nil
 	}
 }
 
-func (c *Client) ListPolicy(flavour Flavour) ([]*PolicyJSON, error) {
+// ListPolicy returns all policies of the given flavour, following ORY Keto's pagination until the last page
+func (c *Client) ListPolicy(ctx context.Context, flavour Flavour) ([]*PolicyJSON, error) {
+	var all []*PolicyJSON
+
+	var previous string
+	opts := &ListOptions{Limit: DefaultPageSize}
+	for opts != nil {
+		page, next, err := c.ListPolicyPage(ctx, flavour, *opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 || repeatsPage(*opts, previous, page[0].Id) {
+			break
+		}
+		previous = page[0].Id
+		all = append(all, page...)
+		opts = next
+	}
+
+	return all, nil
+}
 
+// ListPolicyPage returns a single page of policies together with the options to request the next one,
+// which are nil on the last page
+func (c *Client) ListPolicyPage(ctx context.Context, flavour Flavour, opts ListOptions) ([]*PolicyJSON, *ListOptions, error) {
 	var jsonClientList []*PolicyJSON
 
-	req, err := c.newRequest(http.MethodGet, c.AcpEnginePolicyPath(flavour, ""), nil)
+	req, err := c.newRequest(ctx, http.MethodGet, c.AcpEnginePolicyPath(flavour, ""), nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	opts.apply(req)
 
 	resp, err := c.do(req, &jsonClientList)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return jsonClientList, nil
-	default:
-		return nil, fmt.Errorf("%s %s http request returned unexpected status code %s", req.Method, req.URL.String(), resp.Status)
-	}
+	return jsonClientList, nextPage(resp, opts, len(jsonClientList)), nil
 }
 
-func (c *Client) UpsertPolicy(flavour Flavour, o *PolicyJSON) (*PolicyJSON, error) {
+func (c *Client) UpsertPolicy(ctx context.Context, flavour Flavour, o *PolicyJSON) (*PolicyJSON, error) {
 	var jsonClient *PolicyJSON
 
-	req, err := c.newRequest(http.MethodPut, c.AcpEnginePolicyPath(flavour, ""), o)
+	req, err := c.newRequest(ctx, http.MethodPut, c.AcpEnginePolicyPath(flavour, ""), o)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.do(req, &jsonClient)
-	if err != nil {
+	if _, err := c.do(req, &jsonClient); err != nil {
 		return nil, err
 	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return jsonClient, nil
-	default:
-		return nil, fmt.Errorf("%s %s http request returned unexpected status code: %s", req.Method, req.URL, resp.Status)
-	}
+	return jsonClient, nil
 }
 
-func (c *Client) DeletePolicy(flavour Flavour, id string) error {
+func (c *Client) DeletePolicy(ctx context.Context, flavour Flavour, id string) error {
 
-	req, err := c.newRequest(http.MethodDelete, c.AcpEnginePolicyPath(flavour, id), nil)
+	req, err := c.newRequest(ctx, http.MethodDelete, c.AcpEnginePolicyPath(flavour, id), nil)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.do(req, nil)
-	if err != nil {
-		return err
-	}
-
-	switch resp.StatusCode {
-	case http.StatusNoContent:
-		return nil
-	case http.StatusNotFound:
-		fmt.Printf("client with id %s does not exist", id)
+	_, err = c.do(req, nil)
+	switch {
+	case IsNotFound(err):
+		// already deleted
 		return nil
 	default:
-		return fmt.Errorf("%s %s http request returned unexpected status code %s", req.Method, req.URL.String(), resp.Status)
+		return err
 	}
 }
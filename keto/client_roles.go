@@ -1,97 +1,139 @@
 package keto
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
 func (c *Client) AcpEngineRolePath(flavour Flavour, id string) string {
-	return fmt.Sprintf("/engines/acp/ory/%s/roles/%s", flavour, id)
+	return fmt.Sprintf("/engines/acp/ory/%s/roles/%s", flavour, url.PathEscape(id))
 }
 
-func (c *Client) GetRole(flavour Flavour, id string) (*Role, bool, error) {
+func (c *Client) GetRole(ctx context.Context, flavour Flavour, id string) (*Role, bool, error) {
 	var jsonClient *Role
 
-	req, err := c.newRequest(http.MethodGet, c.AcpEngineRolePath(flavour, id), nil)
+	req, err := c.newRequest(ctx, http.MethodGet, c.AcpEngineRolePath(flavour, id), nil)
 	if err != nil {
 		return nil, false, err
 	}
 
-	resp, err := c.do(req, &jsonClient)
-	if err != nil {
+	_, err = c.do(req, &jsonClient)
+	switch {
+	case IsNotFound(err):
+		return nil, false, nil
+	case err != nil:
 		return nil, false, err
+	default:
+		return jsonClient, true, nil
 	}
+}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return jsonClient, true, nil
-	case http.StatusNotFound:
-		return nil, false, nil
-	default:
-		return nil, false, fmt.Errorf("%s %s http request returned unexpected status code %s", req.Method, req.URL.String(), resp.Status)
+// ListRole returns all roles of the given flavour, following ORY Keto's pagination until the last page
+func (c *Client) ListRole(ctx context.Context, flavour Flavour) ([]*Role, error) {
+	var all []*Role
+
+	var previous string
+	opts := &ListOptions{Limit: DefaultPageSize}
+	for opts != nil {
+		page, next, err := c.ListRolePage(ctx, flavour, *opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 || repeatsPage(*opts, previous, page[0].Id) {
+			break
+		}
+		previous = page[0].Id
+		all = append(all, page...)
+		opts = next
 	}
+
+	return all, nil
 }
 
-func (c *Client) ListRole(flavour Flavour) ([]*Role, error) {
+// ListRolePage returns a single page of roles together with the options to request the next one,
+// which are nil on the last page
+func (c *Client) ListRolePage(ctx context.Context, flavour Flavour, opts ListOptions) ([]*Role, *ListOptions, error) {
 	var jsonClientList []*Role
 
-	req, err := c.newRequest(http.MethodGet, c.AcpEngineRolePath(flavour, ""), nil)
+	req, err := c.newRequest(ctx, http.MethodGet, c.AcpEngineRolePath(flavour, ""), nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	opts.apply(req)
 
 	resp, err := c.do(req, &jsonClientList)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return jsonClientList, nil
-	default:
-		return nil, fmt.Errorf("%s %s http request returned unexpected status code %s", req.Method, req.URL.String(), resp.Status)
-	}
+	return jsonClientList, nextPage(resp, opts, len(jsonClientList)), nil
 }
 
-func (c *Client) UpsertRole(flavour Flavour, o *Role) (*Role, error) {
+func (c *Client) UpsertRole(ctx context.Context, flavour Flavour, o *Role) (*Role, error) {
 	var jsonClient *Role
 
-	req, err := c.newRequest(http.MethodPut, c.AcpEngineRolePath(flavour, ""), o)
+	req, err := c.newRequest(ctx, http.MethodPut, c.AcpEngineRolePath(flavour, ""), o)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.do(req, &jsonClient)
-	if err != nil {
+	if _, err := c.do(req, &jsonClient); err != nil {
 		return nil, err
 	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return jsonClient, nil
+	return jsonClient, nil
+}
+
+func (c *Client) DeleteRole(ctx context.Context, flavour Flavour, id string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, c.AcpEngineRolePath(flavour, id), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(req, nil)
+	switch {
+	case IsNotFound(err):
+		// already deleted
+		return nil
 	default:
-		return nil, fmt.Errorf("%s %s http request returned unexpected status code: %s", req.Method, req.URL, resp.Status)
+		return err
 	}
 }
 
-func (c *Client) DeleteRole(flavour Flavour, id string) error {
-	req, err := c.newRequest(http.MethodDelete, c.AcpEngineRolePath(flavour, id), nil)
+func (c *Client) AcpEngineRoleMembersPath(flavour Flavour, id, member string) string {
+	return fmt.Sprintf("/engines/acp/ory/%s/roles/%s/members/%s", flavour, url.PathEscape(id), url.PathEscape(member))
+}
+
+// AddRoleMembers adds members to the role with the given id, leaving its other members untouched
+func (c *Client) AddRoleMembers(ctx context.Context, flavour Flavour, id string, members []string) (*Role, error) {
+	var jsonClient *Role
+
+	req, err := c.newRequest(ctx, http.MethodPut, c.AcpEngineRoleMembersPath(flavour, id, ""), &AddRoleMember{Members: members})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if _, err := c.do(req, &jsonClient); err != nil {
+		return nil, err
 	}
 
-	resp, err := c.do(req, nil)
+	return jsonClient, nil
+}
+
+// RemoveRoleMember removes a single member from the role with the given id
+func (c *Client) RemoveRoleMember(ctx context.Context, flavour Flavour, id, member string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, c.AcpEngineRoleMembersPath(flavour, id, member), nil)
 	if err != nil {
 		return err
 	}
 
-	switch resp.StatusCode {
-	case http.StatusNoContent:
-		return nil
-	case http.StatusNotFound:
-		fmt.Printf("client with id %s does not exist", id)
+	_, err = c.do(req, nil)
+	switch {
+	case IsNotFound(err):
 		return nil
 	default:
-		return fmt.Errorf("%s %s http request returned unexpected status code %s", req.Method, req.URL.String(), resp.Status)
+		return err
 	}
 }
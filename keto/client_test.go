@@ -1,14 +1,22 @@
 package keto_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/ory/keto-maester/keto"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -86,7 +94,7 @@ func TestCRUD(t *testing.T) {
 				runServer(&c, h)
 
 				//when
-				o, found, err := c.GetPolicy(keto.Exact, testID)
+				o, found, err := c.GetPolicy(context.Background(), keto.Exact, testID)
 
 				//then
 				if tc.err == nil {
@@ -143,7 +151,7 @@ func TestCRUD(t *testing.T) {
 
 				h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 					assert.Equal(fmt.Sprintf("%s%s", c.KetoURL.String(), c.AcpEnginePolicyPath(keto.Exact, "")), fmt.Sprintf("%s://%s%s/", schemeHTTP, req.Host, req.URL.Path))
-					assert.Equal(http.MethodPost, req.Method)
+					assert.Equal(http.MethodPut, req.Method)
 					w.WriteHeader(tc.statusCode)
 					w.Write([]byte(tc.respBody))
 					if new {
@@ -171,10 +179,10 @@ func TestCRUD(t *testing.T) {
 						Subjects:    []string{"users:maria"},
 						Conditions:  conditions,
 					}
-					o, err = c.UpsertPolicy(keto.Exact, testPolicyJSONUpsert)
+					o, err = c.UpsertPolicy(context.Background(), keto.Exact, testPolicyJSONUpsert)
 					expected = testPolicyJSONUpsert
 				} else {
-					o, err = c.UpsertPolicy(keto.Exact, testPolicyUpsert)
+					o, err = c.UpsertPolicy(context.Background(), keto.Exact, testPolicyUpsert)
 					expected = testPolicyUpsert
 				}
 
@@ -207,6 +215,579 @@ func TestCRUD(t *testing.T) {
 		}
 	})
 
+	t.Run("method=get with cancelled context", func(t *testing.T) {
+
+		//given
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			t.Error("request should not have been sent")
+		})
+		runServer(&c, h)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		//when
+		o, found, err := c.GetPolicy(ctx, keto.Exact, testID)
+
+		//then
+		require.Error(t, err)
+		assert.True(errors.Is(err, context.Canceled))
+		assert.False(found)
+		assert.Nil(o)
+	})
+
+}
+
+func TestList(t *testing.T) {
+
+	assert := assert.New(t)
+
+	c := keto.Client{
+		HTTPClient: &http.Client{},
+		KetoURL:    url.URL{Scheme: schemeHTTP},
+	}
+
+	total := 2*keto.DefaultPageSize + 7
+	page := func(offset, limit int) []string {
+		var items []string
+		for i := offset; i < total && i < offset+limit; i++ {
+			items = append(items, fmt.Sprintf(`{"id":"policy-%d"}`, i))
+		}
+		return items
+	}
+
+	for d, withLinks := range map[string]bool{
+		"following link headers": true,
+		"without link headers":   false,
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			var calls int
+			h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				calls++
+				assert.Equal(http.MethodGet, req.Method)
+				limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+				offset, _ := strconv.Atoi(req.URL.Query().Get("offset"))
+				assert.Equal(keto.DefaultPageSize, limit)
+
+				if withLinks {
+					links := []string{fmt.Sprintf(`<%s?limit=%d&offset=0>; rel="first"`, req.URL.Path, limit)}
+					if offset+limit < total {
+						links = append(links, fmt.Sprintf(`<%s?limit=%d&offset=%d>; rel="next"`, req.URL.Path, limit, offset+limit))
+					}
+					w.Header().Set("Link", strings.Join(links, ","))
+				}
+				w.Write([]byte("[" + strings.Join(page(offset, limit), ",") + "]"))
+			})
+			runServer(&c, h)
+
+			//when
+			policies, err := c.ListPolicy(context.Background(), keto.Exact)
+
+			//then
+			require.NoError(t, err)
+			require.Len(t, policies, total)
+			for i, p := range policies {
+				assert.Equal(fmt.Sprintf("policy-%d", i), p.Id)
+			}
+			assert.Equal(3, calls)
+		})
+	}
+
+	for d, items := range map[string]int{
+		"ignoring pagination with more items than the limit": total,
+		"ignoring pagination with a full page":               keto.DefaultPageSize,
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			var calls int
+			h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				calls++
+				w.Write([]byte("[" + strings.Join(page(0, items), ",") + "]"))
+			})
+			runServer(&c, h)
+
+			//when
+			roles, err := c.ListRole(context.Background(), keto.Exact)
+
+			//then
+			require.NoError(t, err)
+			require.Len(t, roles, items)
+			for i, r := range roles {
+				assert.Equal(fmt.Sprintf("policy-%d", i), r.Id)
+			}
+			assert.True(calls <= 2, "listed %d pages", calls)
+		})
+	}
+
+	t.Run("case/single page", func(t *testing.T) {
+
+		//given
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			assert.Equal("10", req.URL.Query().Get("limit"))
+			assert.Equal("20", req.URL.Query().Get("offset"))
+			w.Write([]byte(`[{"id":"test-id"}]`))
+		})
+		runServer(&c, h)
+
+		//when
+		roles, next, err := c.ListRolePage(context.Background(), keto.Exact, keto.ListOptions{Limit: 10, Offset: 20})
+
+		//then
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(testID, roles[0].Id)
+		assert.Nil(next)
+	})
+}
+
+func TestIsAllowed(t *testing.T) {
+
+	assert := assert.New(t)
+
+	c := keto.Client{
+		HTTPClient: &http.Client{},
+		KetoURL:    url.URL{Scheme: schemeHTTP},
+	}
+
+	for d, tc := range map[string]struct {
+		server
+		allowed bool
+	}{
+		"with allowed request": {
+			server:  server{http.StatusOK, `{"allowed":true}`, nil},
+			allowed: true,
+		},
+		"with denied request": {
+			server:  server{http.StatusForbidden, `{"allowed":false}`, nil},
+			allowed: false,
+		},
+		"internal server error when requesting": {
+			server: server{http.StatusInternalServerError, statusInternalServerErrorBody, errors.New("http request returned unexpected status code")},
+		},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				assert.Equal(fmt.Sprintf("%s%s", c.KetoURL.String(), c.AcpEngineAllowedPath(keto.Regex)), fmt.Sprintf("%s://%s%s", schemeHTTP, req.Host, req.URL.Path))
+				assert.Equal(http.MethodPost, req.Method)
+
+				var input keto.AllowedInput
+				require.NoError(t, json.NewDecoder(req.Body).Decode(&input))
+				assert.Equal(keto.AllowedInput{
+					Subject:  "users:maria",
+					Action:   "read",
+					Resource: "resources:articles:1",
+					Context:  map[string]interface{}{"remoteIPAddress": "10.0.0.1"},
+				}, input)
+
+				w.WriteHeader(tc.statusCode)
+				w.Write([]byte(tc.respBody))
+			})
+			runServer(&c, h)
+
+			//when
+			result, err := c.IsAllowed(context.Background(), keto.Regex, "users:maria", "read", "resources:articles:1", map[string]interface{}{"remoteIPAddress": "10.0.0.1"})
+
+			//then
+			if tc.err != nil {
+				require.Error(t, err)
+				assert.Contains(err.Error(), tc.err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			assert.Equal(tc.allowed, result.Allowed)
+		})
+	}
+}
+
+func TestRoleMembers(t *testing.T) {
+
+	assert := assert.New(t)
+
+	c := keto.Client{
+		HTTPClient: &http.Client{},
+		KetoURL:    url.URL{Scheme: schemeHTTP},
+	}
+
+	t.Run("method=put", func(t *testing.T) {
+
+		//given
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			assert.Equal(fmt.Sprintf("%s%s", c.KetoURL.String(), c.AcpEngineRoleMembersPath(keto.Exact, testID, "")), fmt.Sprintf("%s://%s%s/", schemeHTTP, req.Host, req.URL.Path))
+			assert.Equal(http.MethodPut, req.Method)
+
+			var body keto.AddRoleMember
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+			assert.Equal([]string{"users:maria"}, body.Members)
+			w.Write([]byte(`{"id":"test-id","members":["users:petr","users:maria"]}`))
+		})
+		runServer(&c, h)
+
+		//when
+		role, err := c.AddRoleMembers(context.Background(), keto.Exact, testID, []string{"users:maria"})
+
+		//then
+		require.NoError(t, err)
+		assert.Equal(&keto.Role{Id: testID, Members: []string{"users:petr", "users:maria"}}, role)
+	})
+
+	t.Run("method=delete", func(t *testing.T) {
+
+		for d, statusCode := range map[string]int{
+			"removing a member":         http.StatusCreated,
+			"removing a missing member": http.StatusNotFound,
+		} {
+			t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+				//given
+				h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					assert.Equal(fmt.Sprintf("%s%s", c.KetoURL.String(), c.AcpEngineRoleMembersPath(keto.Exact, testID, "users:maria")), fmt.Sprintf("%s://%s%s", schemeHTTP, req.Host, req.URL.Path))
+					assert.Equal(http.MethodDelete, req.Method)
+					w.WriteHeader(statusCode)
+				})
+				runServer(&c, h)
+
+				//when
+				err := c.RemoveRoleMember(context.Background(), keto.Exact, testID, "users:maria")
+
+				//then
+				require.NoError(t, err)
+			})
+		}
+
+		for d, member := range map[string]string{
+			"a slash":         "users:a/b",
+			"a question mark": "users:maria?",
+			"a hash":          "users:#maria",
+			"a percent sign":  "users:100%",
+		} {
+			t.Run(fmt.Sprintf("case/removing a member containing %s", d), func(t *testing.T) {
+
+				//given
+				var path, escapedPath string
+				h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					path, escapedPath = req.URL.Path, req.URL.EscapedPath()
+					assert.Empty(req.URL.RawQuery)
+					w.WriteHeader(http.StatusCreated)
+				})
+				runServer(&c, h)
+
+				//when
+				err := c.RemoveRoleMember(context.Background(), keto.Exact, testID, member)
+
+				//then
+				require.NoError(t, err)
+				assert.Equal(c.KetoURL.Path+"/engines/acp/ory/exact/roles/test-id/members/"+member, path)
+				assert.Equal(c.KetoURL.Path+"/engines/acp/ory/exact/roles/test-id/members/"+url.PathEscape(member), escapedPath)
+			})
+		}
+	})
+}
+
+func TestAuthentication(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "keto-auth")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeCredential := func(key, value string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, key), []byte(value+"\n"), 0600))
+	}
+
+	var received http.Header
+	h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = req.Header
+		w.Write([]byte(testClient))
+	})
+
+	t.Run("case/bearer token reloaded from file", func(t *testing.T) {
+
+		//given
+		c := keto.Client{HTTPClient: &http.Client{}, Auth: &keto.BearerTokenAuth{Source: &keto.FileCredentials{Dir: dir}}}
+		runServer(&c, h)
+		writeCredential(keto.CredentialToken, "first")
+
+		//when
+		_, _, err := c.GetPolicy(context.Background(), keto.Exact, testID)
+
+		//then
+		require.NoError(t, err)
+		assert.Equal("Bearer first", received.Get("Authorization"))
+
+		//when
+		writeCredential(keto.CredentialToken, "rotated")
+		_, _, err = c.GetPolicy(context.Background(), keto.Exact, testID)
+
+		//then
+		require.NoError(t, err)
+		assert.Equal("Bearer rotated", received.Get("Authorization"))
+	})
+
+	t.Run("case/basic auth", func(t *testing.T) {
+
+		//given
+		c := keto.Client{HTTPClient: &http.Client{}, Auth: &keto.BasicAuth{Source: keto.StaticCredentials{
+			keto.CredentialUsername: "keto-maester",
+			keto.CredentialPassword: "secret",
+		}}}
+		runServer(&c, h)
+
+		//when
+		_, _, err := c.GetPolicy(context.Background(), keto.Exact, testID)
+
+		//then
+		require.NoError(t, err)
+		req := http.Request{Header: received}
+		username, password, ok := req.BasicAuth()
+		assert.True(ok)
+		assert.Equal("keto-maester", username)
+		assert.Equal("secret", password)
+	})
+
+	t.Run("case/custom headers", func(t *testing.T) {
+
+		//given
+		c := keto.Client{HTTPClient: &http.Client{}, Auth: &keto.HeaderAuth{Source: keto.StaticCredentials{"X-Api-Key": "key"}}}
+		runServer(&c, h)
+
+		//when
+		_, _, err := c.GetPolicy(context.Background(), keto.Exact, testID)
+
+		//then
+		require.NoError(t, err)
+		assert.Equal("key", received.Get("X-Api-Key"))
+	})
+
+	t.Run("case/missing credential", func(t *testing.T) {
+
+		//given
+		c := keto.Client{HTTPClient: &http.Client{}, Auth: &keto.BearerTokenAuth{Source: keto.StaticCredentials{}}}
+		runServer(&c, h)
+
+		//when
+		_, _, err := c.GetPolicy(context.Background(), keto.Exact, testID)
+
+		//then
+		require.Error(t, err)
+		assert.Contains(err.Error(), keto.CredentialToken)
+	})
+}
+
+func TestHealth(t *testing.T) {
+
+	assert := assert.New(t)
+
+	c := keto.Client{
+		HTTPClient: &http.Client{},
+		KetoURL:    url.URL{Scheme: schemeHTTP},
+		Retry:      keto.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	}
+
+	for d, tc := range map[string]server{
+		"with healthy keto": {
+			http.StatusOK,
+			`{"status":"ok"}`,
+			nil,
+		},
+		"with keto not ready": {
+			http.StatusServiceUnavailable,
+			`{"errors":{"database":"connection refused"}}`,
+			errors.New("http request returned unexpected status code"),
+		},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			var paths []string
+			h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				paths = append(paths, req.URL.Path)
+				w.WriteHeader(tc.statusCode)
+				w.Write([]byte(tc.respBody))
+			})
+			runServer(&c, h)
+
+			//when
+			healthErr := c.Health(context.Background())
+			readyErr := c.Ready(context.Background())
+
+			//then
+			for _, err := range []error{healthErr, readyErr} {
+				if tc.err == nil {
+					require.NoError(t, err)
+				} else {
+					require.Error(t, err)
+					assert.Contains(err.Error(), tc.err.Error())
+				}
+			}
+			assert.Equal([]string{policiesEndpoint + "/health/alive", policiesEndpoint + "/health/ready"}, paths)
+		})
+	}
+}
+
+func TestAPIError(t *testing.T) {
+
+	assert := assert.New(t)
+
+	c := keto.Client{
+		HTTPClient: &http.Client{},
+		KetoURL:    url.URL{Scheme: schemeHTTP},
+	}
+
+	for d, tc := range map[string]struct {
+		server
+		description string
+		requestID   string
+		notFound    bool
+		conflict    bool
+		retryable   bool
+	}{
+		"with keto error body": {
+			server:      server{http.StatusNotFound, statusNotFoundBody, nil},
+			description: "Unable to locate the requested resource",
+			requestID:   "id",
+			notFound:    true,
+		},
+		"with conflict": {
+			server:      server{http.StatusConflict, `{"error":"Conflict","status_code":409}`, nil},
+			description: "Conflict",
+			conflict:    true,
+		},
+		"with plain text body": {
+			server:      server{http.StatusServiceUnavailable, statusInternalServerErrorBody, nil},
+			description: statusInternalServerErrorBody,
+			retryable:   true,
+		},
+		"with bad request": {
+			server:      server{http.StatusBadRequest, `{"error":"Bad Request","error_description":"The request was malformed","status_code":400,"request_id":"abc"}`, nil},
+			description: "The request was malformed",
+			requestID:   "abc",
+		},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(tc.statusCode)
+				w.Write([]byte(tc.respBody))
+			})
+			runServer(&c, h)
+
+			//when
+			_, err := c.UpsertPolicy(context.Background(), keto.Exact, testPolicyUpsert)
+
+			//then
+			require.Error(t, err)
+			apiErr, ok := keto.AsAPIError(err)
+			require.True(t, ok)
+			assert.Equal(tc.statusCode, apiErr.StatusCode)
+			assert.Equal(tc.description, apiErr.Description())
+			assert.Equal(tc.requestID, apiErr.RequestID())
+			assert.Equal(tc.notFound, keto.IsNotFound(err))
+			assert.Equal(tc.conflict, keto.IsConflict(err))
+			assert.Equal(tc.retryable, keto.IsRetryable(err))
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+
+	assert := assert.New(t)
+
+	c := keto.Client{
+		HTTPClient: &http.Client{},
+		KetoURL:    url.URL{Scheme: schemeHTTP},
+		Retry: keto.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     5 * time.Millisecond,
+		},
+	}
+
+	for d, tc := range map[string]struct {
+		failures     int32
+		statusCode   int
+		method       string
+		expectedErr  bool
+		expectedCall int32
+	}{
+		"recovering after transient failures": {
+			failures:     2,
+			statusCode:   http.StatusServiceUnavailable,
+			method:       http.MethodPut,
+			expectedCall: 3,
+		},
+		"giving up after max attempts": {
+			failures:     5,
+			statusCode:   http.StatusBadGateway,
+			method:       http.MethodGet,
+			expectedErr:  true,
+			expectedCall: 3,
+		},
+		"not retrying client errors": {
+			failures:     1,
+			statusCode:   http.StatusBadRequest,
+			method:       http.MethodPut,
+			expectedErr:  true,
+			expectedCall: 1,
+		},
+		"retrying deletes": {
+			failures:     1,
+			statusCode:   http.StatusTooManyRequests,
+			method:       http.MethodDelete,
+			expectedCall: 2,
+		},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			var calls int32
+			h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				assert.Equal(tc.method, req.Method)
+				if atomic.AddInt32(&calls, 1) <= tc.failures {
+					w.WriteHeader(tc.statusCode)
+					w.Write([]byte(statusInternalServerErrorBody))
+					return
+				}
+				if req.Method == http.MethodPut {
+					body, err := ioutil.ReadAll(req.Body)
+					require.NoError(t, err)
+					assert.Contains(string(body), "users:maria")
+				}
+				if req.Method == http.MethodDelete {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.Write([]byte(testClientCreated))
+			})
+			runServer(&c, h)
+
+			//when
+			var err error
+			switch tc.method {
+			case http.MethodGet:
+				_, _, err = c.GetPolicy(context.Background(), keto.Exact, testID)
+			case http.MethodPut:
+				_, err = c.UpsertPolicy(context.Background(), keto.Exact, testPolicyUpsert)
+			case http.MethodDelete:
+				err = c.DeletePolicy(context.Background(), keto.Exact, testID)
+			}
+
+			//then
+			if tc.expectedErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(tc.expectedCall, atomic.LoadInt32(&calls))
+		})
+	}
 }
 
 func runServer(c *keto.Client, h http.HandlerFunc) {
@@ -0,0 +1,81 @@
+package keto
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// PoliciesEqual tells whether two policies grant the same in ORY Keto. The order of subjects, actions
+// and resources doesn't matter, conditions are compared by their meaning rather than their encoding.
+func PoliciesEqual(a, b *PolicyJSON) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Id == b.Id &&
+		a.Description == b.Description &&
+		a.Effect == b.Effect &&
+		sameStrings(a.Subjects, b.Subjects) &&
+		sameStrings(a.Actions, b.Actions) &&
+		sameStrings(a.Resources, b.Resources) &&
+		sameConditions(a.Conditions, b.Conditions)
+}
+
+// RolesEqual tells whether two roles have the same members, in any order
+func RolesEqual(a, b *Role) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Id == b.Id && sameStrings(a.Members, b.Members)
+}
+
+// sameStrings compares a and b as sets
+func sameStrings(a, b []string) bool {
+	return reflect.DeepEqual(stringSet(a), stringSet(b))
+}
+
+func stringSet(items []string) []string {
+	set := append([]string{}, items...)
+	sort.Strings(set)
+
+	unique := set[:0]
+	for i, item := range set {
+		if i == 0 || item != set[i-1] {
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
+
+// sameConditions compares conditions by their decoded value, an absent, null and empty object all mean
+// that the policy has no conditions
+func sameConditions(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+
+	va, err := decodeConditions(a)
+	if err != nil {
+		return false
+	}
+	vb, err := decodeConditions(b)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func decodeConditions(raw json.RawMessage) (map[string]interface{}, error) {
+	conditions := map[string]interface{}{}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return conditions, nil
+	}
+	if err := json.Unmarshal(raw, &conditions); err != nil {
+		return nil, err
+	}
+	if conditions == nil {
+		conditions = map[string]interface{}{}
+	}
+	return conditions, nil
+}
@@ -0,0 +1,104 @@
+package keto_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoliciesEqual(t *testing.T) {
+
+	desired := func() *keto.PolicyJSON {
+		return &keto.PolicyJSON{
+			Id:          "default:policy",
+			Description: "reading users",
+			Effect:      "allow",
+			Subjects:    []string{"alice", "bob"},
+			Actions:     []string{"read", "list"},
+			Resources:   []string{"users"},
+			Conditions:  json.RawMessage(`{"remoteIP":{"type":"CIDRCondition","options":{"cidr":"10.0.0.0/8"}}}`),
+		}
+	}
+
+	for d, tc := range map[string]struct {
+		change func(p *keto.PolicyJSON)
+		equal  bool
+	}{
+		"identical":          {func(p *keto.PolicyJSON) {}, true},
+		"reordered subjects": {func(p *keto.PolicyJSON) { p.Subjects = []string{"bob", "alice"} }, true},
+		"duplicated action":  {func(p *keto.PolicyJSON) { p.Actions = []string{"list", "read", "read"} }, true},
+		"reformatted conditions": {func(p *keto.PolicyJSON) {
+			p.Conditions = json.RawMessage(`{ "remoteIP": { "options": { "cidr": "10.0.0.0/8" }, "type": "CIDRCondition" } }`)
+		}, true},
+		"added subject":       {func(p *keto.PolicyJSON) { p.Subjects = append(p.Subjects, "mallory") }, false},
+		"removed resource":    {func(p *keto.PolicyJSON) { p.Resources = nil }, false},
+		"changed effect":      {func(p *keto.PolicyJSON) { p.Effect = "deny" }, false},
+		"changed description": {func(p *keto.PolicyJSON) { p.Description = "" }, false},
+		"changed condition option": {func(p *keto.PolicyJSON) {
+			p.Conditions = json.RawMessage(`{"remoteIP":{"type":"CIDRCondition","options":{"cidr":"0.0.0.0/0"}}}`)
+		}, false},
+		"removed conditions": {func(p *keto.PolicyJSON) { p.Conditions = nil }, false},
+		"invalid conditions": {func(p *keto.PolicyJSON) { p.Conditions = json.RawMessage(`{`) }, false},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			actual := desired()
+			tc.change(actual)
+
+			//when
+			equal := keto.PoliciesEqual(desired(), actual)
+
+			//then
+			assert.Equal(t, tc.equal, equal)
+		})
+	}
+}
+
+func TestPoliciesEqualWithoutConditions(t *testing.T) {
+
+	for _, conditions := range []json.RawMessage{nil, json.RawMessage(`null`), json.RawMessage(`{}`)} {
+		t.Run(fmt.Sprintf("case/%q", string(conditions)), func(t *testing.T) {
+
+			//given
+			desired := &keto.PolicyJSON{Id: "default:policy"}
+			actual := &keto.PolicyJSON{Id: "default:policy", Conditions: conditions}
+
+			//when
+			equal := keto.PoliciesEqual(desired, actual)
+
+			//then
+			assert.True(t, equal)
+		})
+	}
+}
+
+func TestRolesEqual(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		actual *keto.Role
+		equal  bool
+	}{
+		"same members":      {&keto.Role{Id: "default:role", Members: []string{"alice", "bob"}}, true},
+		"reordered members": {&keto.Role{Id: "default:role", Members: []string{"bob", "alice"}}, true},
+		"missing member":    {&keto.Role{Id: "default:role", Members: []string{"alice"}}, false},
+		"extra member":      {&keto.Role{Id: "default:role", Members: []string{"alice", "bob", "mallory"}}, false},
+		"other ID":          {&keto.Role{Id: "default:other", Members: []string{"alice", "bob"}}, false},
+		"missing role":      {nil, false},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			desired := &keto.Role{Id: "default:role", Members: []string{"alice", "bob"}}
+
+			//when
+			equal := keto.RolesEqual(desired, tc.actual)
+
+			//then
+			assert.Equal(t, tc.equal, equal)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package keto
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+)
+
+// Condition types understood by Ladon, the policy engine of ORY Keto
+const (
+	StringEqualCondition      = "StringEqualCondition"
+	StringMatchCondition      = "StringMatchCondition"
+	CIDRCondition             = "CIDRCondition"
+	EqualsSubjectCondition    = "EqualsSubjectCondition"
+	EqualsCondition           = "EqualsCondition"
+	StringPairsEqualCondition = "StringPairsEqualCondition"
+	ResourceContainsCondition = "ResourceContainsCondition"
+	BooleanCondition          = "BooleanCondition"
+)
+
+// Condition is a single entry of the conditions of a policy, keyed by the name of the request context value it checks
+type Condition struct {
+	Type    string                     `json:"type"`
+	Options map[string]json.RawMessage `json:"options"`
+}
+
+// conditionOptions lists the options of every known condition type, all of them are required
+var conditionOptions = map[string]map[string]func(json.RawMessage) error{
+	StringEqualCondition:      {"equals": isString},
+	StringMatchCondition:      {"matches": isRegexp},
+	CIDRCondition:             {"cidr": isCIDR},
+	EqualsSubjectCondition:    {},
+	EqualsCondition:           {"equals": isAny},
+	StringPairsEqualCondition: {},
+	ResourceContainsCondition: {},
+	BooleanCondition:          {"value": isBool},
+}
+
+// ValidateConditions checks that the conditions of a policy are known Ladon condition types with well-formed options
+func ValidateConditions(conditions json.RawMessage) error {
+	if len(conditions) == 0 || string(conditions) == "null" {
+		return nil
+	}
+
+	var parsed map[string]Condition
+	if err := json.Unmarshal(conditions, &parsed); err != nil {
+		return fmt.Errorf("conditions must map names to objects with a type and options: %s", err)
+	}
+
+	names := make([]string, 0, len(parsed))
+	for name := range parsed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := ValidateCondition(parsed[name]); err != nil {
+			return fmt.Errorf("condition %q: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateCondition checks that c is a known Ladon condition type with well-formed options
+func ValidateCondition(c Condition) error {
+	options, known := conditionOptions[c.Type]
+	if !known {
+		return fmt.Errorf("unknown condition type %q", c.Type)
+	}
+
+	for option := range c.Options {
+		if _, ok := options[option]; !ok {
+			return fmt.Errorf("unknown option %q of %s", option, c.Type)
+		}
+	}
+	for option, validate := range options {
+		value, ok := c.Options[option]
+		if !ok {
+			return fmt.Errorf("missing option %q of %s", option, c.Type)
+		}
+		if err := validate(value); err != nil {
+			return fmt.Errorf("option %q of %s: %s", option, c.Type, err)
+		}
+	}
+	return nil
+}
+
+func isString(value json.RawMessage) error {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return fmt.Errorf("must be a string")
+	}
+	return nil
+}
+
+func isBool(value json.RawMessage) error {
+	var b bool
+	if err := json.Unmarshal(value, &b); err != nil {
+		return fmt.Errorf("must be a boolean")
+	}
+	return nil
+}
+
+func isAny(value json.RawMessage) error {
+	var v interface{}
+	return json.Unmarshal(value, &v)
+}
+
+func isRegexp(value json.RawMessage) error {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return fmt.Errorf("must be a string")
+	}
+	_, err := regexp.Compile(s)
+	return err
+}
+
+func isCIDR(value json.RawMessage) error {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return fmt.Errorf("must be a string")
+	}
+	_, _, err := net.ParseCIDR(s)
+	return err
+}
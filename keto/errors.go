@@ -0,0 +1,136 @@
+package keto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// maxErrorBodySize limits how much of an error response is read into an APIError
+const maxErrorBodySize = 64 * 1024
+
+// ErrorPayload is the JSON error body returned by ORY Keto
+type ErrorPayload struct {
+	Name        string `json:"error,omitempty"`
+	Description string `json:"error_description,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	RequestID   string `json:"request_id,omitempty"`
+}
+
+// APIError is returned when ORY Keto responds with a non-2xx status code
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Payload    ErrorPayload
+}
+
+func newAPIError(req *http.Request, resp *http.Response) *APIError {
+	e := &APIError{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err := json.Unmarshal(body, &e.Payload); err != nil {
+		// not every proxy in front of Keto answers with JSON, so keep whatever text was sent
+		e.Payload = ErrorPayload{Description: strings.TrimSpace(string(body))}
+	}
+	if e.Payload.RequestID == "" {
+		e.Payload.RequestID = resp.Header.Get("X-Request-Id")
+	}
+
+	return e
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s http request returned unexpected status code %s", e.Method, e.URL, e.Status)
+	if e.Payload.Description != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Payload.Description)
+	}
+	if e.Payload.RequestID != "" {
+		msg = fmt.Sprintf("%s (request id %s)", msg, e.Payload.RequestID)
+	}
+	return msg
+}
+
+// RequestID returns the ID ORY Keto assigned to the failed request, if any
+func (e *APIError) RequestID() string {
+	return e.Payload.RequestID
+}
+
+// Description returns the human-readable reason ORY Keto gave for the failure,
+// falling back to the HTTP status when the response carried none
+func (e *APIError) Description() string {
+	if e.Payload.Description != "" {
+		return e.Payload.Description
+	}
+	if e.Payload.Name != "" {
+		return e.Payload.Name
+	}
+	return e.Status
+}
+
+// AsAPIError returns the APIError wrapped in err, if there is one
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// IsNotFound reports whether err was caused by ORY Keto answering 404 Not Found
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err was caused by ORY Keto answering 409 Conflict
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+// IsRetryable reports whether the request that caused err may succeed when sent again,
+// either because ORY Keto reported a transient failure or because it could not be reached at all
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if apiErr, ok := AsAPIError(err); ok {
+		switch apiErr.StatusCode {
+		case http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func hasStatusCode(err error, code int) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == code
+}
@@ -0,0 +1,93 @@
+package keto
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize is the number of items requested per page when listing everything
+const DefaultPageSize = 100
+
+// ListOptions selects a single page of a list request
+type ListOptions struct {
+	// Limit is the maximum number of items returned, zero leaves it to ORY Keto
+	Limit int
+	// Offset is the number of items skipped
+	Offset int
+}
+
+func (o ListOptions) apply(req *http.Request) {
+	q := req.URL.Query()
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	req.URL.RawQuery = q.Encode()
+}
+
+// nextPage returns the options to request the page following the one in resp, or nil if it was the last one.
+// The "next" relation of the Link header is followed when ORY Keto sends one, otherwise a full page is taken
+// as a hint that more items are available. A page holding more items than the limit means the server
+// ignored it and sent everything at once.
+func nextPage(resp *http.Response, current ListOptions, received int) *ListOptions {
+	if current.Limit > 0 && received > current.Limit {
+		return nil
+	}
+
+	var next *ListOptions
+	if links := resp.Header.Get("Link"); links != "" {
+		next = parseNextLink(links, current)
+	} else if current.Limit > 0 && received >= current.Limit {
+		next = &ListOptions{Limit: current.Limit, Offset: current.Offset + received}
+	}
+
+	// guard against servers sending us back to a page we have seen already
+	if next == nil || received == 0 || next.Offset <= current.Offset {
+		return nil
+	}
+	return next
+}
+
+func parseNextLink(header string, current ListOptions) *ListOptions {
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+
+		isNext := false
+		for _, param := range parts[1:] {
+			if strings.Replace(strings.TrimSpace(param), " ", "", -1) == `rel="next"` {
+				isNext = true
+			}
+		}
+		if !isNext {
+			continue
+		}
+
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(parts[0]), "<>"))
+		if err != nil {
+			return nil
+		}
+
+		next := ListOptions{Limit: current.Limit}
+		if limit, err := strconv.Atoi(u.Query().Get("limit")); err == nil {
+			next.Limit = limit
+		}
+		if offset, err := strconv.Atoi(u.Query().Get("offset")); err == nil {
+			next.Offset = offset
+		}
+		return &next
+	}
+	return nil
+}
+
+// repeatsPage tells whether a page starting with the item first repeats the previous page, which starts with
+// previous. Servers ignoring the offset send the same page over and over again.
+func repeatsPage(opts ListOptions, previous, first string) bool {
+	return opts.Offset > 0 && previous == first
+}
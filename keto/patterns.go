@@ -0,0 +1,131 @@
+package keto
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Delimiters of the regular expressions embedded in patterns of the regex flavour
+const (
+	RegexDelimiterStart = '<'
+	RegexDelimiterEnd   = '>'
+)
+
+// ValidatePattern checks that ORY Keto is able to compile a subject, action or resource pattern of the given flavour
+func ValidatePattern(flavour Flavour, pattern string) error {
+	switch flavour {
+	case Regex:
+		_, err := CompileRegex(pattern)
+		return err
+	case Glob:
+		return validateGlob(pattern)
+	default:
+		return nil
+	}
+}
+
+// CompileRegex compiles a pattern of the regex flavour the way ORY Keto does: the parts enclosed in
+// delimiters are regular expressions, everything else is matched literally
+func CompileRegex(pattern string) (*regexp.Regexp, error) {
+	indices, err := delimiterIndices(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	end := 0
+	for i := 0; i < len(indices); i += 2 {
+		expr.WriteString(regexp.QuoteMeta(pattern[end:indices[i]]))
+		expr.WriteString("(" + pattern[indices[i]+1:indices[i+1]-1] + ")")
+		end = indices[i+1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[end:]))
+	expr.WriteString("$")
+
+	compiled, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression in %q: %s", pattern, err)
+	}
+	return compiled, nil
+}
+
+// delimiterIndices returns the start and end offsets of the top level delimited parts of pattern
+func delimiterIndices(pattern string) ([]int, error) {
+	var level, start int
+	var indices []int
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case RegexDelimiterStart:
+			if level++; level == 1 {
+				start = i
+			}
+		case RegexDelimiterEnd:
+			if level--; level == 0 {
+				indices = append(indices, start, i+1)
+			} else if level < 0 {
+				return nil, fmt.Errorf("unbalanced delimiters in %q", pattern)
+			}
+		}
+	}
+	if level != 0 {
+		return nil, fmt.Errorf("unbalanced delimiters in %q", pattern)
+	}
+	return indices, nil
+}
+
+// validateGlob checks the syntax of a glob pattern: escapes, character classes and nested alternatives
+func validateGlob(pattern string) error {
+	runes := []rune(pattern)
+	alternatives := 0
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i++; i == len(runes) {
+				return fmt.Errorf("unfinished escape sequence in %q", pattern)
+			}
+		case '[':
+			end, err := globClassEnd(runes, i)
+			if err != nil {
+				return fmt.Errorf("%s in %q", err, pattern)
+			}
+			i = end
+		case '{':
+			alternatives++
+		case '}':
+			if alternatives > 0 {
+				alternatives--
+			}
+		}
+	}
+	if alternatives > 0 {
+		return fmt.Errorf("unclosed alternatives in %q", pattern)
+	}
+	return nil
+}
+
+// globClassEnd returns the offset of the bracket closing the character class opened at start
+func globClassEnd(runes []rune, start int) (int, error) {
+	i := start + 1
+	if i < len(runes) && runes[i] == '!' {
+		i++
+	}
+
+	var class []rune
+	for ; i < len(runes) && runes[i] != ']'; i++ {
+		class = append(class, runes[i])
+	}
+	if i == len(runes) {
+		return 0, fmt.Errorf("unclosed character class")
+	}
+	if len(class) == 0 {
+		return 0, fmt.Errorf("empty character class")
+	}
+
+	// a class is either a list of characters or a single range
+	if len(class) > 1 && class[1] == '-' && (len(class) != 3 || class[0] > class[2]) {
+		return 0, fmt.Errorf("invalid character range %q", string(class))
+	}
+	return i, nil
+}
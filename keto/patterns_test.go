@@ -0,0 +1,67 @@
+package keto_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePattern(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		flavour keto.Flavour
+		pattern string
+		valid   bool
+	}{
+		"exact with delimiters":          {keto.Exact, "users:<[", true},
+		"regex with expression":          {keto.Regex, "users:<[a-z]+>:<.*>", true},
+		"regex with nested delimiters":   {keto.Regex, "users:<(?P<name>[a-z]+)>", true},
+		"regex with invalid expression":  {keto.Regex, "users:<[a-z>", false},
+		"regex with unbalanced opening":  {keto.Regex, "users:<.*", false},
+		"regex with unbalanced closing":  {keto.Regex, "users:.*>", false},
+		"glob with wildcards":            {keto.Glob, "users:**:{read,write}:?", true},
+		"glob with character classes":    {keto.Glob, "users:[!a-c][xyz]", true},
+		"glob with unclosed class":       {keto.Glob, "users:[a-z", false},
+		"glob with reversed range":       {keto.Glob, "users:[z-a]", false},
+		"glob with unclosed alternative": {keto.Glob, "users:{read,write", false},
+		"glob with trailing escape":      {keto.Glob, `users:\`, false},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//when
+			err := keto.ValidatePattern(tc.flavour, tc.pattern)
+
+			//then
+			assert.Equal(t, tc.valid, err == nil, "unexpected result %v", err)
+		})
+	}
+}
+
+func TestValidateConditions(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		conditions string
+		valid      bool
+	}{
+		"none":                   {`null`, true},
+		"known types":            {`{"owner": {"type": "EqualsSubjectCondition"}, "ip": {"type": "CIDRCondition", "options": {"cidr": "10.0.0.0/8"}}}`, true},
+		"unknown type":           {`{"owner": {"type": "OwnerCondition"}}`, false},
+		"missing option":         {`{"env": {"type": "StringEqualCondition"}}`, false},
+		"unknown option":         {`{"env": {"type": "StringEqualCondition", "options": {"equals": "prod", "matches": "prod"}}}`, false},
+		"mistyped option":        {`{"admin": {"type": "BooleanCondition", "options": {"value": "true"}}}`, false},
+		"invalid cidr":           {`{"ip": {"type": "CIDRCondition", "options": {"cidr": "10.0.0.0"}}}`, false},
+		"invalid regexp":         {`{"env": {"type": "StringMatchCondition", "options": {"matches": "(prod"}}}`, false},
+		"not a map of condition": {`["EqualsSubjectCondition"]`, false},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//when
+			err := keto.ValidateConditions([]byte(tc.conditions))
+
+			//then
+			assert.Equal(t, tc.valid, err == nil, "unexpected result %v", err)
+		})
+	}
+}
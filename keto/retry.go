@@ -0,0 +1,65 @@
+package keto
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy configures how requests that failed with a transient error are sent again
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent, including the first attempt.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, it doubles with every further attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration
+}
+
+// shouldRetry reports whether a request that failed with err on the given attempt is sent again.
+// Only idempotent requests are retried, so that a request which reached Keto can't be applied twice.
+func (p RetryPolicy) shouldRetry(req *http.Request, attempt int, err error) bool {
+	if attempt >= p.MaxAttempts || req.Context().Err() != nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return IsRetryable(err)
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before the given retry using exponential backoff with jitter
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	// keep half of the delay and randomize the rest, so that reconcilers failing together don't retry in lockstep
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// wait blocks until the next attempt is due or ctx is done
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	t := time.NewTimer(p.backoff(attempt))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
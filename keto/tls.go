@@ -0,0 +1,171 @@
+package keto
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSOptions configures the TLS connection to the ORY Keto admin server
+type TLSOptions struct {
+	// CAFile is a PEM bundle used instead of the system roots to verify the server certificate
+	CAFile string
+	// CertFile and KeyFile are the PEM encoded client certificate and key presented for mutual TLS
+	CertFile string
+	KeyFile  string
+	// ServerName is the host name the server certificate is verified against, it is required with CAFile
+	ServerName string
+	// InsecureSkipVerify disables verification of the server certificate, only use it for development
+	InsecureSkipVerify bool
+}
+
+// NewTransport returns an HTTP transport for the given TLS options. The CA bundle and the client certificate
+// are read again whenever the files change on disk, so rotated certificates are picked up on the next
+// TLS handshake without restarting the process.
+func NewTransport(opts TLSOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts == (TLSOptions{}) {
+		return transport, nil
+	}
+
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+	if opts.CAFile != "" && opts.ServerName == "" {
+		return nil, errors.New("server name must be set to verify the server certificate against a CA file")
+	}
+
+	r := &certReloader{opts: opts}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	if opts.CertFile != "" {
+		cfg.GetClientCertificate = r.getClientCertificate
+	}
+	if opts.CAFile != "" && !opts.InsecureSkipVerify {
+		// the standard verification can't see a reloaded pool, so it is replaced by our own
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyPeerCertificate = r.verifyPeerCertificate
+	}
+
+	transport.TLSClientConfig = cfg
+	return transport, nil
+}
+
+type certReloader struct {
+	opts TLSOptions
+
+	mu          sync.Mutex
+	caModTime   time.Time
+	certModTime time.Time
+	keyModTime  time.Time
+	roots       *x509.CertPool
+	cert        *tls.Certificate
+}
+
+// reload reads the files which changed since they were read last
+func (r *certReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.opts.CAFile != "" {
+		modTime, err := modTime(r.opts.CAFile)
+		if err != nil {
+			return err
+		}
+		if !modTime.Equal(r.caModTime) {
+			pem, err := ioutil.ReadFile(r.opts.CAFile)
+			if err != nil {
+				return err
+			}
+			roots := x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("no certificates found in %s", r.opts.CAFile)
+			}
+			r.roots, r.caModTime = roots, modTime
+		}
+	}
+
+	if r.opts.CertFile != "" {
+		certModTime, err := modTime(r.opts.CertFile)
+		if err != nil {
+			return err
+		}
+		keyModTime, err := modTime(r.opts.KeyFile)
+		if err != nil {
+			return err
+		}
+		if !certModTime.Equal(r.certModTime) || !keyModTime.Equal(r.keyModTime) {
+			cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+			if err != nil {
+				return err
+			}
+			r.cert, r.certModTime, r.keyModTime = &cert, certModTime, keyModTime
+		}
+	}
+
+	return nil
+}
+
+func (r *certReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) verifyPeerCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if err := r.reload(); err != nil {
+		return err
+	}
+	if len(rawCerts) == 0 {
+		return errors.New("server presented no certificate")
+	}
+
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	r.mu.Lock()
+	roots := r.roots
+	r.mu.Unlock()
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       r.opts.ServerName,
+	})
+	return err
+}
+
+func modTime(file string) (time.Time, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
@@ -0,0 +1,159 @@
+package keto_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ory/keto-maester/keto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLS(t *testing.T) {
+
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "keto-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var clientSubject string
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(req.TLS.PeerCertificates) > 0 {
+			clientSubject = req.TLS.PeerCertificates[0].Subject.CommonName
+		}
+		w.Write([]byte(testClient))
+	}))
+	s.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	s.StartTLS()
+	defer s.Close()
+
+	serverURL, _ := url.Parse(s.URL)
+	caFile := filepath.Join(dir, "ca.crt")
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	t.Run("case/reloading rotated CA bundle", func(t *testing.T) {
+
+		//given
+		otherCA, _ := selfSignedPEM(t, "other-ca")
+		require.NoError(t, ioutil.WriteFile(caFile, otherCA, 0600))
+
+		transport, err := keto.NewTransport(keto.TLSOptions{CAFile: caFile, ServerName: "example.com"})
+		require.NoError(t, err)
+		c := keto.Client{HTTPClient: &http.Client{Transport: transport}, KetoURL: *serverURL}
+
+		//when
+		_, _, err = c.GetPolicy(context.Background(), keto.Exact, testID)
+
+		//then
+		require.Error(t, err)
+
+		//when
+		serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+		require.NoError(t, ioutil.WriteFile(caFile, serverCA, 0600))
+		require.NoError(t, os.Chtimes(caFile, time.Now(), time.Now().Add(time.Minute)))
+		_, found, err := c.GetPolicy(context.Background(), keto.Exact, testID)
+
+		//then
+		require.NoError(t, err)
+		assert.True(found)
+	})
+
+	t.Run("case/rejecting wrong server name", func(t *testing.T) {
+
+		//given
+		transport, err := keto.NewTransport(keto.TLSOptions{CAFile: caFile, ServerName: "keto.example.org"})
+		require.NoError(t, err)
+		c := keto.Client{HTTPClient: &http.Client{Transport: transport}, KetoURL: *serverURL}
+
+		//when
+		_, _, err = c.GetPolicy(context.Background(), keto.Exact, testID)
+
+		//then
+		require.Error(t, err)
+	})
+
+	t.Run("case/presenting client certificate", func(t *testing.T) {
+
+		//given
+		cert, key := selfSignedPEM(t, "keto-maester")
+		require.NoError(t, ioutil.WriteFile(certFile, cert, 0600))
+		require.NoError(t, ioutil.WriteFile(keyFile, key, 0600))
+
+		transport, err := keto.NewTransport(keto.TLSOptions{CAFile: caFile, ServerName: "example.com", CertFile: certFile, KeyFile: keyFile})
+		require.NoError(t, err)
+		c := keto.Client{HTTPClient: &http.Client{Transport: transport}, KetoURL: *serverURL}
+
+		//when
+		_, _, err = c.GetPolicy(context.Background(), keto.Exact, testID)
+
+		//then
+		require.NoError(t, err)
+		assert.Equal("keto-maester", clientSubject)
+	})
+
+	t.Run("case/skipping verification", func(t *testing.T) {
+
+		//given
+		transport, err := keto.NewTransport(keto.TLSOptions{InsecureSkipVerify: true})
+		require.NoError(t, err)
+		c := keto.Client{HTTPClient: &http.Client{Transport: transport}, KetoURL: *serverURL}
+
+		//when
+		_, found, err := c.GetPolicy(context.Background(), keto.Exact, testID)
+
+		//then
+		require.NoError(t, err)
+		assert.True(found)
+	})
+
+	t.Run("case/requiring certificate and key together", func(t *testing.T) {
+
+		//when
+		_, err := keto.NewTransport(keto.TLSOptions{CertFile: certFile})
+
+		//then
+		require.Error(t, err)
+	})
+}
+
+// selfSignedPEM returns a PEM encoded self-signed certificate and its key
+func selfSignedPEM(t *testing.T, commonName string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
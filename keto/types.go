@@ -29,3 +29,16 @@ type Role struct {
 type AddRoleMember struct {
 	Members []string `json:"members,omitempty"`
 }
+
+// AllowedInput is the access request ORY Keto decides on
+type AllowedInput struct {
+	Subject  string                 `json:"subject"`
+	Action   string                 `json:"action"`
+	Resource string                 `json:"resource"`
+	Context  map[string]interface{} `json:"context,omitempty"`
+}
+
+// AuthorizationResult is the decision ORY Keto made on an AllowedInput
+type AuthorizationResult struct {
+	Allowed bool `json:"allowed"`
+}
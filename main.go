@@ -22,14 +22,20 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	ketov1alpha1 "github.com/ory/keto-maester/api/v1alpha1"
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
 	"github.com/ory/keto-maester/controllers"
+	"github.com/ory/keto-maester/webhooks"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	// +kubebuilder:scaffold:imports
 )
@@ -43,21 +49,59 @@ func init() {
 
 	apiv1.AddToScheme(scheme)
 	ketov1alpha1.AddToScheme(scheme)
+	ketov1alpha2.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var (
-		metricsAddr, ketoURL, forwardedProto, syncPeriod string
-		ketoPort                                         int
-		enableLeaderElection                             bool
+		metricsAddr, ketoURL, forwardedProto, syncPeriod, reconcileTimeout string
+		healthProbeAddr                                                    string
+		ketoInitialBackoff, ketoMaxBackoff                                 string
+		ketoCAFile, ketoCertFile, ketoKeyFile, ketoServerName              string
+		ketoAuth, ketoAuthDir, ketoAuthSecret                              string
+		gcMode, gcInterval, gcIDPrefix, deletionPolicy                     string
+		eventInterval, memberKinds, memberNamespaces, retainedConfigMap    string
+		ketoPort, ketoMaxAttempts, webhookPort                             int
+		enableLeaderElection, ketoInsecureSkipVerify, enableWebhooks       bool
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&healthProbeAddr, "health-probe-addr", ":8081", "The address the liveness (/healthz) and readiness (/readyz) probes bind to. Readiness follows the availability of ORY Keto.")
 	flag.StringVar(&ketoURL, "keto-url", "", "The address of ORY Hydra")
 	flag.IntVar(&ketoPort, "keto-port", 4456, "Port ORY Keto is listening on")
 	flag.StringVar(&forwardedProto, "forwarded-proto", "", "If set, this adds the value as the X-Forwarded-Proto header in requests to the ORY Keto admin server")
+	flag.IntVar(&ketoMaxAttempts, "keto-max-attempts", 3, "Maximum number of attempts for idempotent requests to the ORY Keto admin server that fail with a transient error")
+	flag.StringVar(&ketoInitialBackoff, "keto-initial-backoff", "200ms", "Delay before retrying a failed request to the ORY Keto admin server, doubled on every further attempt")
+	flag.StringVar(&ketoMaxBackoff, "keto-max-backoff", "5s", "Maximum delay between two attempts of a request to the ORY Keto admin server")
+	flag.StringVar(&ketoCAFile, "keto-ca-file", "", "PEM bundle of the certificate authorities used to verify the ORY Keto admin server, reloaded when it changes")
+	flag.StringVar(&ketoCertFile, "keto-cert-file", "", "PEM client certificate presented to the ORY Keto admin server, reloaded when it changes")
+	flag.StringVar(&ketoKeyFile, "keto-key-file", "", "PEM key of the client certificate presented to the ORY Keto admin server, reloaded when it changes")
+	flag.StringVar(&ketoServerName, "keto-server-name", "", "Overrides the host name the certificate of the ORY Keto admin server is verified against")
+	flag.BoolVar(&ketoInsecureSkipVerify, "keto-insecure-skip-verify", false, "Disables verification of the ORY Keto admin server certificate, only use it for development")
+	flag.StringVar(&ketoAuth, "keto-auth", "none", "Authentication for requests to the ORY Keto admin server, one of none, bearer, basic or headers")
+	flag.StringVar(&ketoAuthDir, "keto-auth-dir", "", "Directory with one file per credential (token, username, password or header names), reloaded on every request")
+	flag.StringVar(&ketoAuthSecret, "keto-auth-secret", "", "Secret in the form namespace/name whose keys hold the credentials, used unless keto-auth-dir is set")
 	flag.StringVar(&syncPeriod, "sync-period", "10h", "Determines the minimum frequency at which watched resources are reconciled")
+	flag.StringVar(&reconcileTimeout, "reconcile-timeout", "30s", "Maximum duration of a single reconciliation, including all requests to the ORY Keto admin server")
+	flag.StringVar(&deletionPolicy, "deletion-policy", string(ketov1alpha2.DeletionPolicyDelete), "Whether policies and roles are deleted from ORY Keto or kept there when their objects are deleted, Delete or Retain, unless the objects set their own deletionPolicy")
+	flag.StringVar(&memberKinds, "member-kinds", "", "Comma-separated kinds in the form Kind.group, besides service accounts and Roles, which Roles may resolve members from")
+	flag.StringVar(&memberNamespaces, "member-namespaces", "", "Comma-separated namespaces which Roles of any namespace may resolve members from, by default Roles only see their own namespace")
+	flag.StringVar(&eventInterval, "event-interval", "5m", "Minimum interval between two identical events recorded on the same object, so that a flapping ORY Keto doesn't flood the API server")
+	flag.StringVar(&retainedConfigMap, "retained-configmap", "", "ConfigMap in the form namespace/name recording the policies and roles kept in ORY Keto by the deletion policy Retain, required by gc-mode delete")
+	flag.StringVar(&gcMode, "gc-mode", string(controllers.GCOff), "What to do with policies and roles in ORY Keto owned by the controller but without an object: off, report or delete")
+	flag.StringVar(&gcInterval, "gc-interval", "1h", "Interval between two garbage collections of orphaned policies and roles")
+	flag.StringVar(&gcIDPrefix, "gc-id-prefix", "", "Prefix of the IDs in ORY Keto owned by the controller, by default IDs shaped like namespace:name are owned")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the admission webhooks defaulting and validating Policies and Roles, requires a serving certificate in /tmp/k8s-webhook-server/serving-certs")
+	flag.IntVar(&webhookPort, "webhook-port", 443, "Port the admission webhooks are served on")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 	flag.Parse()
@@ -70,11 +114,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	reconcileTimeoutParsed, err := time.ParseDuration(reconcileTimeout)
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+
+	switch ketov1alpha2.DeletionPolicy(deletionPolicy) {
+	case ketov1alpha2.DeletionPolicyDelete, ketov1alpha2.DeletionPolicyRetain:
+	default:
+		setupLog.Error(fmt.Errorf("deletion policy must be Delete or Retain"), "unable to start manager")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		LeaderElection:     enableLeaderElection,
 		SyncPeriod:         &syncPeriodParsed,
+		Port:               webhookPort,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -92,32 +150,162 @@ func main() {
 		os.Exit(1)
 	}
 
+	ketoInitialBackoffParsed, err := time.ParseDuration(ketoInitialBackoff)
+	if err != nil {
+		setupLog.Error(err, "unable to create keto client")
+		os.Exit(1)
+	}
+
+	ketoMaxBackoffParsed, err := time.ParseDuration(ketoMaxBackoff)
+	if err != nil {
+		setupLog.Error(err, "unable to create keto client")
+		os.Exit(1)
+	}
+
+	if ketoServerName == "" {
+		ketoServerName = u.Hostname()
+	}
+
+	ketoTransport, err := keto.NewTransport(keto.TLSOptions{
+		CAFile:             ketoCAFile,
+		CertFile:           ketoCertFile,
+		KeyFile:            ketoKeyFile,
+		ServerName:         ketoServerName,
+		InsecureSkipVerify: ketoInsecureSkipVerify,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create keto client")
+		os.Exit(1)
+	}
+
+	ketoAuthenticator, err := newKetoAuthenticator(ketoAuth, ketoAuthDir, ketoAuthSecret, mgr.GetAPIReader())
+	if err != nil {
+		setupLog.Error(err, "unable to create keto client")
+		os.Exit(1)
+	}
+
 	ketoClient := &keto.Client{
 		KetoURL:        *u,
-		HTTPClient:     &http.Client{},
+		HTTPClient:     &http.Client{Transport: ketoTransport},
 		ForwardedProto: forwardedProto,
+		Retry: keto.RetryPolicy{
+			MaxAttempts:    ketoMaxAttempts,
+			InitialBackoff: ketoInitialBackoffParsed,
+			MaxBackoff:     ketoMaxBackoffParsed,
+		},
+		Auth: ketoAuthenticator,
+	}
+
+	ketoHealth := &controllers.KetoHealth{KetoClient: ketoClient}
+
+	eventIntervalParsed, err := time.ParseDuration(eventInterval)
+	if err != nil {
+		setupLog.Error(err, "unable to create event recorder")
+		os.Exit(1)
+	}
+	recorder := &controllers.RateLimitedRecorder{
+		Recorder: mgr.GetEventRecorderFor("keto-maester"),
+		Interval: eventIntervalParsed,
+	}
+
+	var retained *controllers.RetainedLocations
+	if retainedConfigMap != "" {
+		name, err := parseNamespacedName(retainedConfigMap)
+		if err != nil {
+			setupLog.Error(fmt.Errorf("retained configmap must be in the form namespace/name"), "unable to start manager")
+			os.Exit(1)
+		}
+		// the ConfigMap is read from the API server, so that the manager doesn't cache all ConfigMaps
+		retained = &controllers.RetainedLocations{Reader: mgr.GetAPIReader(), Writer: mgr.GetClient(), ConfigMap: name}
+	}
+
+	err = mgr.Add(&controllers.HealthServer{
+		Addr:       healthProbeAddr,
+		KetoHealth: ketoHealth,
+		Log:        ctrl.Log.WithName("health"),
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to add health probes")
+		os.Exit(1)
 	}
 
 	err = (&controllers.KetoPolicyReconciler{Reconciler: &controllers.Reconciler{
-		Client:     mgr.GetClient(),
-		Log:        ctrl.Log.WithName("controllers").WithName("Policy"),
-		KetoClient: ketoClient,
+		Client:         mgr.GetClient(),
+		Log:            ctrl.Log.WithName("controllers").WithName("Policy"),
+		KetoClient:     ketoClient,
+		Timeout:        reconcileTimeoutParsed,
+		KetoHealth:     ketoHealth,
+		Recorder:       recorder,
+		DeletionPolicy: ketov1alpha2.DeletionPolicy(deletionPolicy),
+		Retained:       retained,
 	}}).SetupWithManager(mgr)
 	if err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Policy")
 		os.Exit(1)
 	}
 
+	var memberGroupKinds []schema.GroupKind
+	for _, kind := range splitList(memberKinds) {
+		memberGroupKinds = append(memberGroupKinds, schema.ParseGroupKind(kind))
+	}
+
 	err = (&controllers.KetoRoleReconciler{Reconciler: &controllers.Reconciler{
-		Client:     mgr.GetClient(),
-		Log:        ctrl.Log.WithName("controllers").WithName("Role"),
-		KetoClient: ketoClient,
-	}}).SetupWithManager(mgr)
+		Client:         mgr.GetClient(),
+		Log:            ctrl.Log.WithName("controllers").WithName("Role"),
+		KetoClient:     ketoClient,
+		Timeout:        reconcileTimeoutParsed,
+		KetoHealth:     ketoHealth,
+		Recorder:       recorder,
+		DeletionPolicy: ketov1alpha2.DeletionPolicy(deletionPolicy),
+		Retained:       retained,
+	},
+		MemberKinds:      memberGroupKinds,
+		MemberNamespaces: splitList(memberNamespaces),
+	}).SetupWithManager(mgr)
 	if err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Role")
 		os.Exit(1)
 	}
 
+	switch controllers.GCMode(gcMode) {
+	case controllers.GCOff, controllers.GCReport, controllers.GCDelete:
+	default:
+		setupLog.Error(fmt.Errorf("gc mode must be one of off, report or delete"), "unable to create garbage collector")
+		os.Exit(1)
+	}
+	if controllers.GCMode(gcMode) == controllers.GCDelete && retained == nil {
+		setupLog.Error(fmt.Errorf("gc mode delete requires retained-configmap, otherwise policies and roles retained by their deletion policy would be deleted"), "unable to create garbage collector")
+		os.Exit(1)
+	}
+
+	gcIntervalParsed, err := time.ParseDuration(gcInterval)
+	if err != nil {
+		setupLog.Error(err, "unable to create garbage collector")
+		os.Exit(1)
+	}
+
+	if controllers.GCMode(gcMode) != controllers.GCOff {
+		err = mgr.Add(&controllers.GarbageCollector{
+			KetoClient: ketoClient,
+			Client:     mgr.GetClient(),
+			Log:        ctrl.Log.WithName("gc"),
+			Mode:       controllers.GCMode(gcMode),
+			Interval:   gcIntervalParsed,
+			IDPrefix:   gcIDPrefix,
+			KetoHealth: ketoHealth,
+			Recorder:   recorder,
+			Retained:   retained,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create garbage collector")
+			os.Exit(1)
+		}
+	}
+
+	if enableWebhooks {
+		webhooks.Register(mgr.GetWebhookServer())
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -126,3 +314,57 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func newKetoAuthenticator(authType, dir, secret string, reader client.Reader) (keto.Authenticator, error) {
+	if authType == "none" {
+		return nil, nil
+	}
+
+	var source keto.CredentialsSource
+	switch {
+	case dir != "":
+		source = &keto.FileCredentials{Dir: dir}
+	case secret != "":
+		name, err := parseNamespacedName(secret)
+		if err != nil {
+			return nil, fmt.Errorf("keto auth secret must be in the form namespace/name")
+		}
+		source = &controllers.SecretCredentials{
+			Reader: reader,
+			Secret: name,
+		}
+	default:
+		return nil, fmt.Errorf("keto auth %s requires a credentials directory or secret", authType)
+	}
+
+	switch authType {
+	case "bearer":
+		return &keto.BearerTokenAuth{Source: source}, nil
+	case "basic":
+		return &keto.BasicAuth{Source: source}, nil
+	case "headers":
+		return &keto.HeaderAuth{Source: source}, nil
+	default:
+		return nil, fmt.Errorf("unknown keto auth %s", authType)
+	}
+}
+
+// parseNamespacedName parses a flag value in the form namespace/name
+func parseNamespacedName(value string) (types.NamespacedName, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, fmt.Errorf("%q is not in the form namespace/name", value)
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
+
+// splitList splits a comma-separated flag value, ignoring empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
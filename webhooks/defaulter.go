@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/mutate-keto-ory-sh-policy,mutating=true,failurePolicy=fail,groups=keto.ory.sh,resources=policies,verbs=create;update,versions=v1alpha1;v1alpha2,name=mpolicy.keto.ory.sh
+// +kubebuilder:webhook:path=/mutate-keto-ory-sh-role,mutating=true,failurePolicy=fail,groups=keto.ory.sh,resources=roles,verbs=create;update,versions=v1alpha1;v1alpha2,name=mrole.keto.ory.sh
+
+// Defaultable is implemented by the hub versions of the API types defaulted on admission
+type Defaultable interface {
+	conversion.Hub
+	SetDefaults()
+}
+
+// Defaulter fills in optional fields and normalizes objects before they are stored, so that cosmetic changes
+// don't bump the generation of the object. Objects are defaulted in the hub version and converted back
+// to the version they were sent in.
+type Defaulter struct {
+	hubDecoder
+
+	// Hub returns an empty object of the hub version of the defaulted kind
+	Hub func() Defaultable
+}
+
+func (d *Defaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	hub := d.Hub()
+	obj, err := d.decode(req, hub)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	hub.SetDefaults()
+
+	var defaulted interface{} = hub
+	if spoke, ok := obj.(conversion.Convertible); ok {
+		if err := spoke.ConvertFrom(hub); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		defaulted = spoke
+	}
+
+	marshaled, err := json.Marshal(defaulted)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
@@ -0,0 +1,119 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	ketov1alpha1 "github.com/ory/keto-maester/api/v1alpha1"
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestDefaulter(t *testing.T) {
+
+	policyDefaulter := &Defaulter{hubDecoder: newTestHubDecoder(t), Hub: func() Defaultable { return &ketov1alpha2.Policy{} }}
+	roleDefaulter := &Defaulter{hubDecoder: newTestHubDecoder(t), Hub: func() Defaultable { return &ketov1alpha2.Role{} }}
+	meta := metav1.ObjectMeta{Namespace: "default", Name: "readers"}
+
+	for d, tc := range map[string]struct {
+		defaulter *Defaulter
+		obj       runtime.Object
+		// defaulted is what the patch turns obj into, nil if nothing is to be patched
+		defaulted runtime.Object
+	}{
+		"unnormalized policy": {
+			defaulter: policyDefaulter,
+			obj: &ketov1alpha2.Policy{
+				TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha2.GroupVersion.String(), Kind: "Policy"},
+				ObjectMeta: meta,
+				Spec:       ketov1alpha2.PolicySpec{Subjects: []string{" bob", "alice", "bob"}, Actions: []string{"read"}, Resources: []string{"books"}},
+			},
+			defaulted: &ketov1alpha2.Policy{
+				TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha2.GroupVersion.String(), Kind: "Policy"},
+				ObjectMeta: meta,
+				Spec:       ketov1alpha2.PolicySpec{Flavour: "exact", Subjects: []string{"alice", "bob"}, Actions: []string{"read"}, Resources: []string{"books"}, Effect: ketov1alpha2.DefaultEffect},
+			},
+		},
+		"normalized policy": {
+			defaulter: policyDefaulter,
+			obj: &ketov1alpha2.Policy{
+				TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha2.GroupVersion.String(), Kind: "Policy"},
+				ObjectMeta: meta,
+				Spec:       ketov1alpha2.PolicySpec{Flavour: "exact", Subjects: []string{"alice"}, Actions: []string{"read"}, Resources: []string{"books"}, Effect: ketov1alpha2.EffectAllow},
+			},
+		},
+		"unnormalized role": {
+			defaulter: roleDefaulter,
+			obj: &ketov1alpha2.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha2.GroupVersion.String(), Kind: "Role"},
+				ObjectMeta: meta,
+				Spec:       ketov1alpha2.RoleSpec{Flavour: "glob", Members: []string{"bob ", "alice"}},
+			},
+			defaulted: &ketov1alpha2.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha2.GroupVersion.String(), Kind: "Role"},
+				ObjectMeta: meta,
+				Spec:       ketov1alpha2.RoleSpec{Flavour: "glob", Members: []string{"alice", "bob"}, MembershipMode: ketov1alpha2.DefaultMembershipMode},
+			},
+		},
+		"normalized role": {
+			defaulter: roleDefaulter,
+			obj: &ketov1alpha2.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha2.GroupVersion.String(), Kind: "Role"},
+				ObjectMeta: meta,
+				Spec:       ketov1alpha2.RoleSpec{Flavour: "exact", Members: []string{"alice", "bob"}, MembershipMode: ketov1alpha2.DefaultMembershipMode},
+			},
+		},
+		"v1alpha1 policy in its own version": {
+			defaulter: policyDefaulter,
+			obj: &ketov1alpha1.Policy{
+				TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha1.GroupVersion.String(), Kind: "Policy"},
+				ObjectMeta: meta,
+				Spec:       ketov1alpha1.PolicySpec{Subjects: []string{"alice"}, Actions: []string{"read"}, Resources: []string{"books"}, Effect: "allow"},
+			},
+			defaulted: &ketov1alpha1.Policy{
+				TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha1.GroupVersion.String(), Kind: "Policy"},
+				ObjectMeta: meta,
+				Spec:       ketov1alpha1.PolicySpec{PatternMatching: "exact", Subjects: []string{"alice"}, Actions: []string{"read"}, Resources: []string{"books"}, Effect: "allow"},
+			},
+		},
+	} {
+		t.Run("case/"+d, func(t *testing.T) {
+
+			//given
+			req := admissionRequest(t, v1beta1.Create, tc.obj, nil)
+
+			//when
+			response := tc.defaulter.Handle(context.Background(), req)
+
+			//then
+			require.True(t, response.Allowed, "%v", response.Result)
+			if tc.defaulted == nil {
+				assert.Empty(t, response.Patches)
+				return
+			}
+			assert.Equal(t, tc.defaulted, patched(t, req.Object.Raw, response, tc.obj))
+		})
+	}
+}
+
+// patched applies the patches of response to original and decodes the result into an object of the type of obj
+func patched(t *testing.T, original []byte, response admission.Response, obj runtime.Object) runtime.Object {
+	encoded, err := json.Marshal(response.Patches)
+	require.NoError(t, err)
+	patch, err := jsonpatch.DecodePatch(encoded)
+	require.NoError(t, err)
+	applied, err := patch.Apply(original)
+	require.NoError(t, err)
+
+	result := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
+	require.NoError(t, json.Unmarshal(applied, result))
+	return result
+}
@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// hubDecoder decodes the object of an admission request into the hub version of its kind, so that objects
+// of every served version are defaulted and validated the same way
+type hubDecoder struct {
+	scheme  *runtime.Scheme
+	decoder *admission.Decoder
+}
+
+func (d *hubDecoder) InjectScheme(s *runtime.Scheme) error {
+	d.scheme = s
+	return nil
+}
+
+func (d *hubDecoder) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// decode fills hub with the object of req and returns the object in the version it was sent in
+func (d *hubDecoder) decode(req admission.Request, hub conversion.Hub) (runtime.Object, error) {
+	return d.decodeRaw(req, req.Object, hub)
+}
+
+// decodeRaw fills hub with raw, an object of the kind and version of req
+func (d *hubDecoder) decodeRaw(req admission.Request, raw runtime.RawExtension, hub conversion.Hub) (runtime.Object, error) {
+	gvk := schema.GroupVersionKind{Group: req.Kind.Group, Version: req.Kind.Version, Kind: req.Kind.Kind}
+	obj, err := d.scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.decoder.DecodeRaw(raw, obj); err != nil {
+		return nil, err
+	}
+
+	switch o := obj.(type) {
+	case conversion.Hub:
+		return obj, d.decoder.DecodeRaw(raw, hub)
+	case conversion.Convertible:
+		return obj, o.ConvertTo(hub)
+	default:
+		return nil, fmt.Errorf("%s can't be converted to %T", gvk, hub)
+	}
+}
@@ -0,0 +1,113 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/validate-keto-ory-sh-policy,mutating=false,failurePolicy=fail,groups=keto.ory.sh,resources=policies,verbs=create;update,versions=v1alpha1;v1alpha2,name=vpolicy.keto.ory.sh
+// +kubebuilder:webhook:path=/validate-keto-ory-sh-role,mutating=false,failurePolicy=fail,groups=keto.ory.sh,resources=roles,verbs=create;update,versions=v1alpha1;v1alpha2,name=vrole.keto.ory.sh
+
+// Validatable is implemented by the hub versions of the API types checked on admission
+type Validatable interface {
+	conversion.Hub
+	metav1.Object
+	Validate() field.ErrorList
+
+	// KetoKey identifies the object in ORY Keto, objects of the same kind with the same key overwrite each other
+	KetoKey() string
+}
+
+// Validator rejects objects ORY Keto would refuse, so that bad manifests fail when they are applied
+// rather than showing up later in the status of the object. Objects are validated in the hub version,
+// so the paths of reported fields are the ones of the hub version.
+type Validator struct {
+	hubDecoder
+	client client.Client
+
+	// Hub returns an empty object of the hub version of the validated kind
+	Hub func() Validatable
+
+	// List returns all objects of the validated kind, to reject objects claiming the ID in ORY Keto of another one
+	List func(ctx context.Context, c client.Reader) ([]Validatable, error)
+}
+
+func (v *Validator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	// deleted objects have nothing to check, and the request has no object
+	if req.Operation == v1beta1.Delete {
+		return admission.Allowed("")
+	}
+
+	hub := v.Hub()
+	if _, err := v.decode(req, hub); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	// the namespace may only be given by the path of the request, while the default ID depends on it
+	if hub.GetNamespace() == "" {
+		hub.SetNamespace(req.Namespace)
+	}
+
+	errs := hub.Validate()
+	conflicts, err := v.ketoIDConflicts(ctx, req, hub)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	errs = append(errs, conflicts...)
+
+	if len(errs) > 0 {
+		kind := schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}
+		invalid := apierrors.NewInvalid(kind, req.Name, errs)
+		return admission.Response{AdmissionResponse: v1beta1.AdmissionResponse{Allowed: false, Result: &invalid.ErrStatus}}
+	}
+	return admission.Allowed("")
+}
+
+// ketoIDConflicts rejects obj if another object already claims its ID in ORY Keto. Updates are only checked
+// if they change the ID, so that objects which already conflict can still be edited and deleted.
+func (v *Validator) ketoIDConflicts(ctx context.Context, req admission.Request, obj Validatable) (field.ErrorList, error) {
+	if v.List == nil || v.client == nil {
+		return nil, nil
+	}
+
+	if req.Operation == v1beta1.Update {
+		old := v.Hub()
+		if _, err := v.decodeRaw(req, req.OldObject, old); err != nil {
+			return nil, err
+		}
+		old.SetNamespace(obj.GetNamespace())
+		if old.KetoKey() == obj.KetoKey() {
+			return nil, nil
+		}
+	}
+
+	others, err := v.List(ctx, v.client)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, other := range others {
+		if other.GetNamespace() == obj.GetNamespace() && other.GetName() == obj.GetName() {
+			continue
+		}
+		if other.KetoKey() == obj.KetoKey() {
+			message := fmt.Sprintf("the ID in ORY Keto is already claimed by %s/%s", other.GetNamespace(), other.GetName())
+			return field.ErrorList{field.Invalid(field.NewPath("spec", "ketoId"), obj.KetoKey(), message)}, nil
+		}
+	}
+	return nil, nil
+}
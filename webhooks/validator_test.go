@@ -0,0 +1,129 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	ketov1alpha1 "github.com/ory/keto-maester/api/v1alpha1"
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestPolicyValidator(t *testing.T, objs ...runtime.Object) *Validator {
+	v := &Validator{
+		hubDecoder: newTestHubDecoder(t),
+		Hub:        func() Validatable { return &ketov1alpha2.Policy{} },
+		List:       listPolicies,
+	}
+	require.NoError(t, v.InjectClient(fake.NewFakeClientWithScheme(scheme.Scheme, objs...)))
+	return v
+}
+
+func testPolicy(name, ketoID string) *ketov1alpha2.Policy {
+	return &ketov1alpha2.Policy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha2.GroupVersion.String(), Kind: "Policy"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: ketov1alpha2.PolicySpec{
+			KetoID:    ketoID,
+			Subjects:  []string{"alice"},
+			Actions:   []string{"read"},
+			Resources: []string{"books"},
+			Effect:    ketov1alpha2.EffectAllow,
+		},
+	}
+}
+
+func TestValidator(t *testing.T) {
+
+	for d, tc := range map[string]struct {
+		operation v1beta1.Operation
+		obj, old  runtime.Object
+		existing  []runtime.Object
+		allowed   bool
+	}{
+		"create":                           {operation: v1beta1.Create, obj: testPolicy("readers", ""), allowed: true},
+		"create of an invalid policy":      {operation: v1beta1.Create, obj: testPolicy("readers", "books/readers"), allowed: false},
+		"create with a free ID":            {operation: v1beta1.Create, obj: testPolicy("readers", "books:readers"), existing: []runtime.Object{testPolicy("writers", "books:writers")}, allowed: true},
+		"create with a claimed ID":         {operation: v1beta1.Create, obj: testPolicy("readers", "books:readers"), existing: []runtime.Object{testPolicy("owner", "books:readers")}, allowed: false},
+		"create with a generated ID":       {operation: v1beta1.Create, obj: testPolicy("readers", ""), existing: []runtime.Object{testPolicy("owner", "default:readers")}, allowed: false},
+		"update of the same policy":        {operation: v1beta1.Update, obj: testPolicy("readers", "books:readers"), old: testPolicy("readers", ""), existing: []runtime.Object{testPolicy("readers", "")}, allowed: true},
+		"update claiming its own ID":       {operation: v1beta1.Update, obj: testPolicy("readers", "default:readers"), old: testPolicy("readers", ""), existing: []runtime.Object{testPolicy("readers", "")}, allowed: true},
+		"update to a claimed ID":           {operation: v1beta1.Update, obj: testPolicy("readers", "books:readers"), old: testPolicy("readers", ""), existing: []runtime.Object{testPolicy("readers", ""), testPolicy("owner", "books:readers")}, allowed: false},
+		"update of an already conflicting": {operation: v1beta1.Update, obj: testPolicy("readers", "books:readers"), old: testPolicy("readers", "books:readers"), existing: []runtime.Object{testPolicy("owner", "books:readers")}, allowed: true},
+		"delete":                           {operation: v1beta1.Delete, obj: testPolicy("readers", "books/readers"), existing: []runtime.Object{testPolicy("owner", "books/readers")}, allowed: true},
+	} {
+		t.Run(fmt.Sprintf("case/%s", d), func(t *testing.T) {
+
+			//given
+			v := newTestPolicyValidator(t, tc.existing...)
+
+			//when
+			response := v.Handle(context.Background(), admissionRequest(t, tc.operation, tc.obj, tc.old))
+
+			//then
+			assert.Equal(t, tc.allowed, response.Allowed, "%v", response.Result)
+		})
+	}
+
+	t.Run("reports the policy claiming the ID", func(t *testing.T) {
+
+		//given
+		v := newTestPolicyValidator(t, testPolicy("owner", "books:readers"))
+
+		//when
+		response := v.Handle(context.Background(), admissionRequest(t, v1beta1.Create, testPolicy("readers", "books:readers"), nil))
+
+		//then
+		require.False(t, response.Allowed)
+		assert.Contains(t, response.Result.Message, "spec.ketoId")
+		assert.Contains(t, response.Result.Message, "already claimed by default/owner")
+	})
+
+	t.Run("validates v1alpha1 policies as v1alpha2", func(t *testing.T) {
+
+		//given
+		policy := &ketov1alpha1.Policy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha1.GroupVersion.String(), Kind: "Policy"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "readers"},
+			Spec: ketov1alpha1.PolicySpec{
+				PatternMatching: "regex",
+				Subjects:        []string{"users:<[a-z]+"},
+				Actions:         []string{"read"},
+				Resources:       []string{"books"},
+				Effect:          "allow",
+			},
+		}
+		v := newTestPolicyValidator(t)
+
+		//when
+		response := v.Handle(context.Background(), admissionRequest(t, v1beta1.Create, policy, nil))
+
+		//then
+		require.False(t, response.Allowed)
+		assert.Contains(t, response.Result.Message, "spec.subjects[0]")
+	})
+
+	t.Run("finds conflicts of v1alpha1 policies", func(t *testing.T) {
+
+		//given
+		policy := &ketov1alpha1.Policy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: ketov1alpha1.GroupVersion.String(), Kind: "Policy"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "readers"},
+			Spec:       ketov1alpha1.PolicySpec{Subjects: []string{"alice"}, Actions: []string{"read"}, Resources: []string{"books"}, Effect: "allow"},
+		}
+		v := newTestPolicyValidator(t, testPolicy("owner", "default:readers"))
+
+		//when
+		response := v.Handle(context.Background(), admissionRequest(t, v1beta1.Create, policy, nil))
+
+		//then
+		assert.False(t, response.Allowed)
+	})
+}
@@ -0,0 +1,54 @@
+package webhooks
+
+import (
+	"context"
+
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// Register serves the admission webhooks of Policy and Role on server. The conversion webhook
+// is served by every webhook server of the manager.
+func Register(server *webhook.Server) {
+	server.Register("/mutate-keto-ory-sh-policy", &webhook.Admission{Handler: &Defaulter{
+		Hub: func() Defaultable { return &ketov1alpha2.Policy{} },
+	}})
+	server.Register("/mutate-keto-ory-sh-role", &webhook.Admission{Handler: &Defaulter{
+		Hub: func() Defaultable { return &ketov1alpha2.Role{} },
+	}})
+	server.Register("/validate-keto-ory-sh-policy", &webhook.Admission{Handler: &Validator{
+		Hub:  func() Validatable { return &ketov1alpha2.Policy{} },
+		List: listPolicies,
+	}})
+	server.Register("/validate-keto-ory-sh-role", &webhook.Admission{Handler: &Validator{
+		Hub:  func() Validatable { return &ketov1alpha2.Role{} },
+		List: listRoles,
+	}})
+}
+
+func listPolicies(ctx context.Context, c client.Reader) ([]Validatable, error) {
+	var list ketov1alpha2.PolicyList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	items := make([]Validatable, len(list.Items))
+	for i := range list.Items {
+		items[i] = &list.Items[i]
+	}
+	return items, nil
+}
+
+func listRoles(ctx context.Context, c client.Reader) ([]Validatable, error) {
+	var list ketov1alpha2.RoleList
+	if err := c.List(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	items := make([]Validatable, len(list.Items))
+	for i := range list.Items {
+		items[i] = &list.Items[i]
+	}
+	return items, nil
+}
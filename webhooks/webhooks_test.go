@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"testing"
+
+	ketov1alpha1 "github.com/ory/keto-maester/api/v1alpha1"
+	ketov1alpha2 "github.com/ory/keto-maester/api/v1alpha2"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func init() {
+	// the fake client decodes with the client-go scheme
+	if err := ketov1alpha2.AddToScheme(scheme.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+// newTestHubDecoder returns a decoder of both versions, as injected by the webhook server
+func newTestHubDecoder(t *testing.T) hubDecoder {
+	s := runtime.NewScheme()
+	require.NoError(t, ketov1alpha1.AddToScheme(s))
+	require.NoError(t, ketov1alpha2.AddToScheme(s))
+	decoder, err := admission.NewDecoder(s)
+	require.NoError(t, err)
+	return hubDecoder{scheme: s, decoder: decoder}
+}
+
+// admissionRequest returns a request of operation on obj, sent in the version of its type meta
+func admissionRequest(t *testing.T, operation v1beta1.Operation, obj, old runtime.Object) admission.Request {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	req := admission.Request{AdmissionRequest: v1beta1.AdmissionRequest{
+		Operation: operation,
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Namespace: "default",
+	}}
+	if operation != v1beta1.Delete {
+		raw, err := json.Marshal(obj)
+		require.NoError(t, err)
+		req.Object = runtime.RawExtension{Raw: raw}
+	}
+	if old != nil {
+		raw, err := json.Marshal(old)
+		require.NoError(t, err)
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	return req
+}